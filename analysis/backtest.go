@@ -0,0 +1,530 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"mnmlsm/ibkr"
+	"mnmlsm/web"
+)
+
+// Roll policies for the short option of a backtest
+const (
+	RollNone   = "none"   // Hold every option to expiry
+	RollProfit = "profit" // Buy back once RollProfitPercent of the premium is captured, then sell the next one
+	RollDTE    = "dte"    // Buy back RollAtDTE days before expiry and roll to the next expiry
+)
+
+// RollPolicies lists the valid backtest roll policies
+var RollPolicies = []string{RollNone, RollProfit, RollDTE}
+
+// Default backtest rules: 30-delta options about a month out, held to expiry
+const (
+	DefaultBacktestDelta     = 0.30
+	DefaultBacktestDTE       = 30
+	DefaultRollProfitPercent = 50
+	DefaultRollAtDTE         = 7
+)
+
+// Sources of the implied volatility options are priced with on a backtest day
+const (
+	IVSourceHistory = "history" // Stored ATM IV history
+	IVSourceFixed   = "fixed"   // BacktestParams.IV assumption
+	IVSourceHV      = "hv"      // Historical volatility of the bars, when neither is available
+)
+
+// backtestIVMaxAge is how many calendar days a stored IV observation prices options for
+const backtestIVMaxAge = 7
+
+// BacktestParams are the wheel rules and market data of a backtest
+type BacktestParams struct {
+	Symbol string
+	Bars   []ibkr.Bar // Daily history, oldest first; bars before Start warm up historical volatility
+	Start  string     // First trading day, 2006-01-02 (empty = first bar)
+	End    string     // Last trading day, 2006-01-02 (empty = last bar)
+
+	PutDelta   float64 // Target |delta| of cash-secured puts (0 = DefaultBacktestDelta)
+	CallDelta  float64 // Target |delta| of covered calls (0 = DefaultBacktestDelta)
+	DTE        int     // Days to expiry of new options; expiries fall on the Friday on or before (0 = DefaultBacktestDTE)
+	MinReturn  float64 // Minimum annualized % net of commission on the capital at risk to sell an option
+	BelowBasis bool    // Allow covered calls struck below the share cost basis
+
+	RollPolicy        string  // RollNone, RollProfit or RollDTE (empty = RollNone)
+	RollProfitPercent float64 // Share of the premium captured before buying back under RollProfit (0 = DefaultRollProfitPercent)
+	RollAtDTE         int     // Days before expiry to roll under RollDTE (0 = DefaultRollAtDTE)
+
+	Contracts int     // Puts sold at a time (0 = 1)
+	Capital   float64 // Starting cash (0 = enough to secure Contracts puts at the first close)
+
+	IV          float64                 // Fixed IV assumption as a fraction (0 = none)
+	IVHistory   []web.IVObservation     // Stored ATM IV, preferred over IV within a week of an observation
+	Commissions *web.CommissionSchedule // Commission and fees on option trades (nil = none)
+}
+
+// BacktestEquity is the value of a backtest account at one day's close
+type BacktestEquity struct {
+	Date   string
+	Equity float64
+}
+
+// BacktestResult is the trade ledger and performance of a wheel backtest. The ledger uses the
+// transaction shapes of the portfolio CSVs so it can be saved and reported on like real trades.
+type BacktestResult struct {
+	Symbol string
+	Start  string
+	End    string
+
+	Options []web.OptionTransaction
+	Stocks  []web.StockTransaction
+	Equity  []BacktestEquity
+
+	StartCapital float64
+	EndEquity    float64
+	EndShares    float64 // Shares still held at the end
+	EndPrice     float64 // Close of the last day
+
+	PremiumCollected float64 // Sell to open credits
+	PremiumPaid      float64 // Buy to close debits
+	Commissions      float64
+
+	PutsSold   int
+	CallsSold  int
+	Expired    int
+	Assigned   int // Puts assigned (shares bought)
+	CalledAway int // Calls assigned (shares sold)
+	Closed     int // Bought back at RollProfitPercent
+	Rolled     int // Bought back to roll under RollDTE
+	IdleDays   int // Days flat because no option met MinReturn or the cash couldn't secure a put
+
+	IVDays map[string]int // Trading days priced from each IV source
+
+	TotalReturn      float64 // % of StartCapital
+	AnnualizedReturn float64 // Compounded annual %
+	MaxDrawdown      float64 // Largest peak-to-trough fall of equity, %
+	BuyHoldReturn    float64 // % return of holding the stock over the same days
+}
+
+// backtestOption is the short option a backtest currently holds
+type backtestOption struct {
+	open     web.OptionTransaction
+	right    string
+	perShare float64 // Premium received per share
+	expiry   time.Time
+}
+
+// Backtest simulates the wheel over daily bars: sell cash-secured puts until assigned, then sell
+// covered calls on the shares until called away, and repeat. Options are priced with
+// Black-Scholes at each day's close and settle against the close of their expiry day.
+func Backtest(params BacktestParams) (*BacktestResult, error) {
+	if err := params.applyDefaults(); err != nil {
+		return nil, err
+	}
+
+	first, last := -1, -1
+	for i, bar := range params.Bars {
+		if (params.Start == "" || bar.Date >= params.Start) && (params.End == "" || bar.Date <= params.End) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 || last == first {
+		return nil, fmt.Errorf("%s: need at least two bars between %s and %s", params.Symbol, orAll(params.Start), orAll(params.End))
+	}
+	lastDate, err := time.Parse("2006-01-02", params.Bars[last].Date)
+	if err != nil {
+		return nil, fmt.Errorf("parsing bar date %q: %w", params.Bars[last].Date, err)
+	}
+
+	b := &backtester{
+		params: params,
+		result: &BacktestResult{
+			Symbol: params.Symbol,
+			Start:  params.Bars[first].Date,
+			End:    params.Bars[last].Date,
+			IVDays: make(map[string]int),
+		},
+		history: web.SymbolIVHistory(params.IVHistory, params.Symbol),
+	}
+
+	b.cash = params.Capital
+	if b.cash == 0 {
+		b.cash = params.Bars[first].Close * 100 * float64(params.Contracts)
+	}
+	b.result.StartCapital = b.cash
+
+	for i := first; i <= last; i++ {
+		bar := params.Bars[i]
+		date, err := time.Parse("2006-01-02", bar.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parsing bar date %q: %w", bar.Date, err)
+		}
+
+		vol, source := b.impliedVol(i)
+		if source != "" {
+			b.result.IVDays[source]++
+		}
+
+		if b.option != nil {
+			b.manage(bar, date, vol)
+		}
+		if b.option == nil && vol > 0 {
+			b.sell(bar, date, vol, lastDate)
+		}
+
+		optionValue := 0.0
+		if b.option != nil {
+			dte := daysBetween(date, b.option.expiry)
+			optionValue = ModelPrice(b.option.right, bar.Close, b.option.open.Strike, vol, dte) * 100 * float64(b.option.open.Contracts)
+		}
+		b.result.Equity = append(b.result.Equity, BacktestEquity{
+			Date:   bar.Date,
+			Equity: b.cash + b.shares*bar.Close - optionValue,
+		})
+	}
+
+	b.summarize(params.Bars[first].Close, params.Bars[last].Close)
+	return b.result, nil
+}
+
+// applyDefaults fills in unset rules and checks the rest
+func (p *BacktestParams) applyDefaults() error {
+	p.Symbol = strings.ToUpper(p.Symbol)
+	if p.PutDelta == 0 {
+		p.PutDelta = DefaultBacktestDelta
+	}
+	if p.CallDelta == 0 {
+		p.CallDelta = DefaultBacktestDelta
+	}
+	if p.DTE == 0 {
+		p.DTE = DefaultBacktestDTE
+	}
+	if p.RollPolicy == "" {
+		p.RollPolicy = RollNone
+	}
+	if p.RollProfitPercent == 0 {
+		p.RollProfitPercent = DefaultRollProfitPercent
+	}
+	if p.RollAtDTE == 0 {
+		p.RollAtDTE = DefaultRollAtDTE
+	}
+	if p.Contracts == 0 {
+		p.Contracts = 1
+	}
+
+	switch {
+	case p.PutDelta < 0 || p.PutDelta >= 1 || p.CallDelta < 0 || p.CallDelta >= 1:
+		return fmt.Errorf("target deltas must be between 0 and 1")
+	case p.DTE < 1:
+		return fmt.Errorf("DTE must be at least 1")
+	case p.RollPolicy != RollNone && p.RollPolicy != RollProfit && p.RollPolicy != RollDTE:
+		return fmt.Errorf("unknown roll policy %q (want %s)", p.RollPolicy, strings.Join(RollPolicies, ", "))
+	case p.RollProfitPercent <= 0 || p.RollProfitPercent >= 100:
+		return fmt.Errorf("roll profit must be between 0 and 100%%")
+	case p.RollPolicy == RollDTE && p.RollAtDTE >= p.DTE:
+		return fmt.Errorf("rolling %d days before expiry needs a DTE above %d", p.RollAtDTE, p.RollAtDTE)
+	case p.Contracts < 0 || p.Capital < 0 || p.IV < 0:
+		return fmt.Errorf("contracts, capital and IV can't be negative")
+	}
+	return nil
+}
+
+// backtester is the account state of a running backtest
+type backtester struct {
+	params  BacktestParams
+	result  *BacktestResult
+	history []web.IVObservation // The symbol's IV history, oldest first
+	ivIndex int                 // Next history observation not yet reached
+
+	cash      float64
+	shares    float64
+	basis     float64 // Cost per share of the shares held
+	option    *backtestOption
+	positions int
+}
+
+// impliedVol returns the IV to price options with on bar i and where it came from: the latest
+// stored IV of the past backtestIVMaxAge days, the fixed assumption, or else the bars' historical
+// volatility
+func (b *backtester) impliedVol(i int) (float64, string) {
+	date := b.params.Bars[i].Date
+	for b.ivIndex < len(b.history) && b.history[b.ivIndex].Date <= date {
+		b.ivIndex++
+	}
+	if b.ivIndex > 0 {
+		observed := b.history[b.ivIndex-1]
+		from, errFrom := time.Parse("2006-01-02", observed.Date)
+		to, errTo := time.Parse("2006-01-02", date)
+		if errFrom == nil && errTo == nil && daysBetween(from, to) <= backtestIVMaxAge {
+			return observed.IV, IVSourceHistory
+		}
+	}
+	if b.params.IV > 0 {
+		return b.params.IV, IVSourceFixed
+	}
+	if hv := closeToCloseVol(b.params.Bars[:i+1], DefaultHVWindow); hv > 0 {
+		return hv, IVSourceHV
+	}
+	return 0, ""
+}
+
+// manage settles the open option at expiry, or buys it back when the roll policy says so
+func (b *backtester) manage(bar ibkr.Bar, date time.Time, vol float64) {
+	opt := b.option
+	shares := float64(opt.open.Contracts * 100)
+
+	if !date.Before(opt.expiry) {
+		itm := bar.Close < opt.open.Strike
+		if opt.right == "C" {
+			itm = bar.Close > opt.open.Strike
+		}
+		if !itm {
+			b.close(bar, "Expired", 0, 0, "")
+			b.result.Expired++
+			return
+		}
+
+		b.close(bar, "Assigned", 0, 0, "")
+		amount := opt.open.Strike * shares
+		if opt.right == "P" {
+			b.cash -= amount
+			b.shares += shares
+			b.basis = opt.open.Strike
+			b.result.Assigned++
+			b.recordStock(bar.Date, "Buy", shares, opt.open.Strike)
+		} else {
+			b.cash += amount
+			b.shares -= shares
+			b.result.CalledAway++
+			b.recordStock(bar.Date, "Sell", shares, opt.open.Strike)
+		}
+		return
+	}
+
+	dte := daysBetween(date, opt.expiry)
+	var notes string
+	switch b.params.RollPolicy {
+	case RollProfit:
+		value := ModelPrice(opt.right, bar.Close, opt.open.Strike, vol, dte)
+		if vol > 0 && value <= opt.perShare*(1-b.params.RollProfitPercent/100) {
+			notes = fmt.Sprintf("Closed at %.0f%% profit", b.params.RollProfitPercent)
+		}
+	case RollDTE:
+		if dte <= b.params.RollAtDTE && vol > 0 {
+			notes = fmt.Sprintf("Rolled %d days before expiry", dte)
+		}
+	}
+	if notes == "" {
+		return
+	}
+
+	perShare := roundCents(ModelPrice(opt.right, bar.Close, opt.open.Strike, vol, dte))
+	commission := b.commission(opt.open.Contracts, perShare, false)
+	cost := perShare * shares
+	b.cash -= cost + commission
+	b.result.PremiumPaid += cost
+	b.result.Commissions += commission
+	if b.params.RollPolicy == RollDTE {
+		b.result.Rolled++
+	} else {
+		b.result.Closed++
+	}
+	b.close(bar, "Buy to Close", -cost, commission, notes)
+}
+
+// close records the end of the open option
+func (b *backtester) close(bar ibkr.Bar, action string, premium, commission float64, notes string) {
+	tx := b.option.open
+	tx.Date = bar.Date
+	tx.Action = action
+	tx.Premium = premium
+	tx.StockPrice = 0
+	if action == "Buy to Close" {
+		tx.StockPrice = bar.Close
+	}
+	tx.Commission = commission
+	tx.Notes = notes
+	b.result.Options = append(b.result.Options, tx)
+	b.option = nil
+}
+
+// sell opens the next leg of the wheel: a covered call while shares are held, otherwise a
+// cash-secured put. Nothing is sold when no strike meets MinReturn, the cash can't secure the
+// put, or the option would expire after the last bar.
+func (b *backtester) sell(bar ibkr.Bar, date time.Time, vol float64, lastDate time.Time) {
+	expiry := backtestExpiry(date, b.params.DTE)
+	if expiry.After(lastDate) {
+		return
+	}
+	dte := daysBetween(date, expiry)
+
+	right, target, floor, contracts := "P", b.params.PutDelta, 0.0, b.params.Contracts
+	if b.shares >= 100 {
+		right, target, contracts = "C", b.params.CallDelta, int(b.shares)/100
+		if !b.params.BelowBasis {
+			floor = b.basis
+		}
+	}
+
+	strike := backtestStrike(right, bar.Close, vol, dte, target, floor)
+	perShare := roundCents(ModelPrice(right, bar.Close, strike, vol, dte))
+	shares := float64(contracts * 100)
+	premium := perShare * shares
+	commission := b.commission(contracts, perShare, true)
+
+	capital := strike * shares
+	if right == "C" {
+		capital = b.basis * shares
+	}
+	annualized := (premium - commission) / capital * 365 / float64(dte) * 100
+	if perShare < 0.01 || annualized < b.params.MinReturn || (right == "P" && b.cash < capital) {
+		b.result.IdleDays++
+		return
+	}
+
+	b.positions++
+	optionType := "Put"
+	if right == "C" {
+		optionType = "Call"
+		b.result.CallsSold++
+	} else {
+		b.result.PutsSold++
+	}
+	b.cash += premium - commission
+	b.result.PremiumCollected += premium
+	b.result.Commissions += commission
+
+	open := web.OptionTransaction{
+		Date:       bar.Date,
+		Action:     "Sell to Open",
+		Symbol:     b.params.Symbol,
+		OptionType: optionType,
+		Strike:     strike,
+		Expiry:     expiry.Format("2006-01-02"),
+		Contracts:  contracts,
+		Premium:    premium,
+		StockPrice: bar.Close,
+		Commission: commission,
+		PositionID: fmt.Sprintf("B%d", b.positions),
+		Notes:      fmt.Sprintf("Backtest: %.2f delta, IV %.0f%%", math.Abs(ModelDelta(right, bar.Close, strike, vol, dte)), vol*100),
+	}
+	b.result.Options = append(b.result.Options, open)
+	b.option = &backtestOption{open: open, right: right, perShare: perShare, expiry: expiry}
+}
+
+// recordStock adds an assignment to the stock ledger
+func (b *backtester) recordStock(date, action string, shares, price float64) {
+	b.result.Stocks = append(b.result.Stocks, web.StockTransaction{
+		Date:          date,
+		Type:          action,
+		Symbol:        b.params.Symbol,
+		Shares:        shares,
+		Price:         price,
+		Amount:        shares * price,
+		TransactionID: fmt.Sprintf("B%d", len(b.result.Stocks)+1),
+	})
+}
+
+// commission returns the commission and fees of an options order, or 0 without a schedule
+func (b *backtester) commission(contracts int, perShare float64, sell bool) float64 {
+	if b.params.Commissions == nil {
+		return 0
+	}
+	return b.params.Commissions.OptionCommission(contracts, perShare, sell)
+}
+
+// summarize computes the performance of the finished backtest
+func (b *backtester) summarize(firstClose, lastClose float64) {
+	r := b.result
+	r.EndShares = b.shares
+	r.EndPrice = lastClose
+	r.EndEquity = r.Equity[len(r.Equity)-1].Equity
+	r.TotalReturn = (r.EndEquity/r.StartCapital - 1) * 100
+	r.BuyHoldReturn = (lastClose/firstClose - 1) * 100
+
+	start, _ := time.Parse("2006-01-02", r.Start)
+	end, _ := time.Parse("2006-01-02", r.End)
+	if days := end.Sub(start).Hours() / 24; days > 0 && r.EndEquity > 0 {
+		r.AnnualizedReturn = (math.Pow(r.EndEquity/r.StartCapital, 365/days) - 1) * 100
+	}
+
+	peak := 0.0
+	for _, point := range r.Equity {
+		peak = math.Max(peak, point.Equity)
+		if peak > 0 {
+			r.MaxDrawdown = math.Max(r.MaxDrawdown, (peak-point.Equity)/peak*100)
+		}
+	}
+}
+
+// backtestExpiry returns the Friday on or before dte days after date, or the next Friday if
+// that is not after date
+func backtestExpiry(date time.Time, dte int) time.Time {
+	expiry := date.AddDate(0, 0, dte)
+	for expiry.Weekday() != time.Friday {
+		expiry = expiry.AddDate(0, 0, -1)
+	}
+	for !expiry.After(date) {
+		expiry = expiry.AddDate(0, 0, 7)
+	}
+	return expiry
+}
+
+// backtestStrikeStep is the listed strike spacing assumed for a stock price
+func backtestStrikeStep(price float64) float64 {
+	switch {
+	case price < 25:
+		return 0.5
+	case price < 200:
+		return 1
+	default:
+		return 5
+	}
+}
+
+// backtestStrike picks the out-of-the-money strike whose model delta is closest to target, on
+// a strike grid within 50% of the price. Calls are struck at or above floor when it is set.
+func backtestStrike(right string, price, vol float64, dte int, target, floor float64) float64 {
+	step := backtestStrikeStep(price)
+	strike := math.Floor(price/step) * step
+	limit := price * 0.5
+	if right == "C" {
+		strike = math.Ceil(math.Max(price, floor)/step) * step
+		limit = math.Max(price, floor) * 1.5
+	}
+
+	best, bestDiff := strike, math.Inf(1)
+	for strike > 0 && ((right == "P" && strike >= limit) || (right == "C" && strike <= limit)) {
+		diff := math.Abs(math.Abs(ModelDelta(right, price, strike, vol, dte)) - target)
+		if diff < bestDiff {
+			best, bestDiff = strike, diff
+		}
+		if right == "P" {
+			strike -= step
+		} else {
+			strike += step
+		}
+	}
+	return best
+}
+
+// daysBetween returns the calendar days from one date to a later one
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+// roundCents rounds a price to the cent
+func roundCents(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+// orAll labels an open end of a date range
+func orAll(date string) string {
+	if date == "" {
+		return "any date"
+	}
+	return date
+}
@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mnmlsm/ibkr"
+)
+
+// DefaultBarsDir is where daily price history is stored for backtests, one CSV per symbol
+const DefaultBarsDir = "data/bars"
+
+// barsHeader is the column layout of stored daily bars
+var barsHeader = []string{"Date", "Open", "High", "Low", "Close", "Volume"}
+
+// BarsFile returns the stored daily bars file of a symbol
+func BarsFile(dir, symbol string) string {
+	return filepath.Join(dir, strings.ToUpper(symbol)+".csv")
+}
+
+// LoadBars reads stored daily bars, oldest first
+func LoadBars(filename string) ([]ibkr.Bar, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	var bars []ibkr.Bar
+	for i, record := range records {
+		if i == 0 || len(record) < len(barsHeader) {
+			continue
+		}
+		values := make([]float64, 5)
+		for j := range values {
+			values[j], _ = strconv.ParseFloat(record[j+1], 64)
+		}
+		if values[3] <= 0 {
+			continue
+		}
+		bars = append(bars, ibkr.Bar{
+			Date:   strings.TrimSpace(record[0]),
+			Open:   values[0],
+			High:   values[1],
+			Low:    values[2],
+			Close:  values[3],
+			Volume: values[4],
+		})
+	}
+
+	sort.Slice(bars, func(i, j int) bool { return bars[i].Date < bars[j].Date })
+	return bars, nil
+}
+
+// SaveBars writes daily bars to CSV, oldest first
+func SaveBars(filename string, bars []ibkr.Bar) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write(barsHeader); err != nil {
+		return err
+	}
+	for _, bar := range bars {
+		record := []string{
+			bar.Date,
+			strconv.FormatFloat(bar.Open, 'f', -1, 64),
+			strconv.FormatFloat(bar.High, 'f', -1, 64),
+			strconv.FormatFloat(bar.Low, 'f', -1, 64),
+			strconv.FormatFloat(bar.Close, 'f', -1, 64),
+			strconv.FormatFloat(bar.Volume, 'f', -1, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeBars adds fetched bars to stored ones, replacing stored bars of the same day
+func MergeBars(stored, fetched []ibkr.Bar) []ibkr.Bar {
+	byDate := make(map[string]ibkr.Bar, len(stored)+len(fetched))
+	for _, bar := range stored {
+		byDate[bar.Date] = bar
+	}
+	for _, bar := range fetched {
+		byDate[bar.Date] = bar
+	}
+
+	merged := make([]ibkr.Bar, 0, len(byDate))
+	for _, bar := range byDate {
+		merged = append(merged, bar)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Date < merged[j].Date })
+	return merged
+}
+
+// UpdateBars fetches up to count daily bars of a symbol from the provider and merges them into
+// its stored history in dir. Returns the full stored history.
+func UpdateBars(provider MarketDataProvider, dir, symbol, exchange string, count int) ([]ibkr.Bar, error) {
+	barProvider, ok := provider.(BarProvider)
+	if !ok {
+		return nil, fmt.Errorf("market data provider has no price history")
+	}
+
+	conID, err := provider.LookupUnderlying(symbol, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("looking up %s: %w", symbol, err)
+	}
+	fetched, err := barProvider.DailyBars(conID, count)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s bars: %w", symbol, err)
+	}
+
+	filename := BarsFile(dir, symbol)
+	stored, err := LoadBars(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	merged := MergeBars(stored, fetched)
+	if err := SaveBars(filename, merged); err != nil {
+		return nil, fmt.Errorf("saving %s: %w", filename, err)
+	}
+	return merged, nil
+}
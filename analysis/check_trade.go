@@ -0,0 +1,307 @@
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// Check outcomes, from best to worst
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Proposed trade types
+const (
+	TradePut   = "put"   // Sell cash-secured puts
+	TradeCall  = "call"  // Sell covered calls
+	TradeStock = "stock" // Buy shares
+)
+
+// checkWarnFraction is the share of a percentage limit past which a check warns
+const checkWarnFraction = 0.9
+
+// ProposedTrade is an option sale or stock purchase to check against the rules
+type ProposedTrade struct {
+	Symbol    string  `json:"symbol"`
+	Type      string  `json:"type"`                // "put", "call" or "stock"
+	Strike    float64 `json:"strike,omitempty"`    // Options only
+	Expiry    string  `json:"expiry,omitempty"`    // Options only, 2006-01-02
+	Premium   float64 `json:"premium,omitempty"`   // Options only, per share
+	Contracts int     `json:"contracts,omitempty"` // Options only (0 = 1)
+	Shares    float64 `json:"shares,omitempty"`    // Stock only
+	Price     float64 `json:"price,omitempty"`     // Stock price (0 = last price in universe.csv)
+}
+
+// CheckResult is the outcome of one rule for a proposed trade
+type CheckResult struct {
+	Rule    string `json:"rule"` // Rule ID from data/rules.json
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// TradeCheck is the compliance verdict for a proposed trade
+type TradeCheck struct {
+	Trade   ProposedTrade `json:"trade"`
+	Status  string        `json:"status"`  // Worst status of the checks
+	Capital float64       `json:"capital"` // Cash the trade ties up
+	Checks  []CheckResult `json:"checks"`
+}
+
+// ErrInvalidTrade marks errors in the proposed trade itself, as opposed to failures loading
+// the book or rules it is checked against
+var ErrInvalidTrade = errors.New("invalid trade")
+
+// invalidTrade returns a validation error that matches ErrInvalidTrade
+func invalidTrade(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidTrade, fmt.Sprintf(format, args...))
+}
+
+// CheckTrade simulates adding a proposed trade to the current book and checks it against
+// the enabled rules in data/rules.json
+func CheckTrade(proposed ProposedTrade) (*TradeCheck, error) {
+	proposed.Symbol = strings.ToUpper(strings.TrimSpace(proposed.Symbol))
+	proposed.Type = strings.ToLower(proposed.Type)
+	if proposed.Symbol == "" {
+		return nil, invalidTrade("symbol is required")
+	}
+
+	isOption := proposed.Type == TradePut || proposed.Type == TradeCall
+	var expiry time.Time
+	switch {
+	case isOption:
+		if proposed.Strike <= 0 {
+			return nil, invalidTrade("strike is required for %s trades", proposed.Type)
+		}
+		var err error
+		expiry, err = time.Parse("2006-01-02", proposed.Expiry)
+		if err != nil {
+			return nil, invalidTrade("parsing expiry %q: %v", proposed.Expiry, err)
+		}
+		if proposed.Contracts <= 0 {
+			proposed.Contracts = 1
+		}
+	case proposed.Type == TradeStock:
+		if proposed.Shares <= 0 {
+			return nil, invalidTrade("shares are required for stock trades")
+		}
+	default:
+		return nil, invalidTrade("unknown trade type %q (want put, call or stock)", proposed.Type)
+	}
+
+	if proposed.Price <= 0 {
+		proposed.Price = web.LoadStockPrices("data/universe.csv")[proposed.Symbol]
+	}
+	if proposed.Type == TradeStock && proposed.Price <= 0 {
+		return nil, invalidTrade("no price for %s; pass one", proposed.Symbol)
+	}
+
+	rules := web.LoadTradingRules("data/rules.json")
+	portfolio, err := LoadPortfolioSnapshot(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cash the trade ties up: puts are secured by cash, covered calls by shares we hold
+	capital := 0.0
+	switch proposed.Type {
+	case TradePut:
+		capital = proposed.Strike * 100 * float64(proposed.Contracts)
+	case TradeStock:
+		capital = proposed.Price * proposed.Shares
+	}
+
+	check := &TradeCheck{
+		Trade:   proposed,
+		Capital: capital,
+	}
+	add := func(rule, status, format string, args ...interface{}) {
+		check.Checks = append(check.Checks, CheckResult{Rule: rule, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Covered calls must be covered by shares not already written against
+	if proposed.Type == TradeCall {
+		shares := 0.0
+		for _, h := range LoadHoldings("data/stocks_transactions.csv") {
+			if h.Symbol == proposed.Symbol {
+				shares = h.Shares
+			}
+		}
+		written := openCallContracts(proposed.Symbol)
+		free := int(math.Floor(shares/100)) - written
+		if proposed.Contracts > free {
+			add(web.RuleCashSecuredOnly, CheckFail, "%d calls need %d shares; %.0f held, %d contracts already written", proposed.Contracts, proposed.Contracts*100, shares, written)
+		} else {
+			add(web.RuleCashSecuredOnly, CheckPass, "Covered by %.0f shares (%d contracts free)", shares, free)
+		}
+	}
+
+	// No margin: the trade must be paid for with cash
+	if capital > portfolio.DryPowder {
+		add(web.RuleNoMargin, CheckFail, "Needs $%.0f, only $%.0f dry powder", capital, portfolio.DryPowder)
+	} else {
+		add(web.RuleNoMargin, CheckPass, "$%.0f of $%.0f dry powder", capital, portfolio.DryPowder)
+	}
+
+	// Cash reserve, raised by the VIX tier
+	if rules.Enabled(web.RuleCashMinimum) || rules.Enabled(web.RuleVIXDeployment) {
+		if capital > portfolio.Deployable {
+			add(web.RuleCashMinimum, CheckFail, "Needs $%.0f, only $%.0f deployable above the $%.0f reserve (%.0f%%)", capital, portfolio.Deployable, portfolio.Reserve, portfolio.ReservePercent)
+		} else {
+			add(web.RuleCashMinimum, CheckPass, "$%.0f still deployable above the $%.0f reserve (%.0f%%)", portfolio.Deployable-capital, portfolio.Reserve, portfolio.ReservePercent)
+		}
+	}
+
+	// Exposure is reduced once the VIX leaves the first (least restrictive) tier, the same tiers
+	// that set the reserve above
+	if rules.Enabled(web.RuleVIXDeployment) {
+		tier, ok := rules.VIXTier(portfolio.VIX)
+		switch {
+		case !ok:
+			add(web.RuleVIXDeployment, CheckPass, "VIX %.2f, no deployment tiers configured", portfolio.VIX)
+		case tier.MaxDeployment < rules.VIXTiers[0].MaxDeployment:
+			add(web.RuleVIXDeployment, CheckWarn, "VIX %.2f (%s): reduce exposure, max %.0f%% deployed", portfolio.VIX, tier.Regime, tier.MaxDeployment)
+		default:
+			add(web.RuleVIXDeployment, CheckPass, "VIX %.2f (%s): max %.0f%% deployed", portfolio.VIX, tier.Regime, tier.MaxDeployment)
+		}
+	}
+
+	// Position and sector concentration after the trade
+	if rules.Enabled(web.RuleMaxPosition) && portfolio.NetWorth > 0 {
+		percent := (portfolio.ExistingCapital(proposed.Symbol) + capital) / portfolio.NetWorth * 100
+		add(web.RuleMaxPosition, limitStatus(percent, rules.Threshold(web.RuleMaxPosition)),
+			"%s at %.1f%% of net worth (max %.0f%%)", proposed.Symbol, percent, rules.Threshold(web.RuleMaxPosition))
+	}
+
+	if rules.Enabled(web.RuleMaxSector) && portfolio.NetWorth > 0 {
+		sector := web.LoadSectorMapping("data/universe.csv")[proposed.Symbol]
+		if sector == "" {
+			add(web.RuleMaxSector, CheckWarn, "No sector for %s in universe.csv", proposed.Symbol)
+		} else {
+			percent := (getCurrentSectorExposure()[sector] + capital) / portfolio.NetWorth * 100
+			add(web.RuleMaxSector, limitStatus(percent, rules.Threshold(web.RuleMaxSector)),
+				"%s at %.1f%% of net worth (max %.0f%%)", sector, percent, rules.Threshold(web.RuleMaxSector))
+		}
+	}
+
+	// Earnings during the life of the option, or within the lookahead for stock
+	if rules.Enabled(web.RuleNoEarnings) {
+		now := time.Now()
+		end := expiry
+		if !isOption {
+			end = now.AddDate(0, 0, int(rules.Threshold(web.RuleNoEarnings)))
+		}
+		if event, ok := web.NextEarnings(web.LoadEvents("data/events.csv"), proposed.Symbol, now, end); ok {
+			add(web.RuleNoEarnings, CheckFail, "Earnings on %s before %s", event.Date, end.Format("2006-01-02"))
+		} else {
+			add(web.RuleNoEarnings, CheckPass, "No earnings before %s", end.Format("2006-01-02"))
+		}
+	}
+
+	// Annualized return on the premium's time value
+	if isOption {
+		checkReturn(proposed, expiry, rules, add)
+	}
+
+	check.Status = CheckPass
+	for _, c := range check.Checks {
+		if statusRank(c.Status) > statusRank(check.Status) {
+			check.Status = c.Status
+		}
+	}
+
+	return check, nil
+}
+
+// checkReturn checks the annualized return of an option sale: on the strike for puts (rule 11),
+// on cost basis for covered calls (rule 12)
+func checkReturn(proposed ProposedTrade, expiry time.Time, rules web.TradingRules, add func(rule, status, format string, args ...interface{})) {
+	days := int(math.Ceil(time.Until(expiry).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+
+	// Only time value counts as return; intrinsic value is paid back on assignment
+	intrinsic := 0.0
+	if proposed.Price > 0 {
+		if proposed.Type == TradePut {
+			intrinsic = math.Max(0, proposed.Strike-proposed.Price)
+		} else {
+			intrinsic = math.Max(0, proposed.Price-proposed.Strike)
+		}
+	}
+	extrinsic := math.Max(0, proposed.Premium-intrinsic)
+
+	rule := web.RuleMinReturn
+	basis := proposed.Strike
+	basisLabel := "strike"
+	if proposed.Type == TradeCall {
+		for _, h := range LoadHoldings("data/stocks_transactions.csv") {
+			if h.Symbol == proposed.Symbol {
+				basis = h.CostBasis
+				basisLabel = "cost basis"
+			}
+		}
+		if proposed.Price > 0 && proposed.Price < basis {
+			rule = web.RuleCoveredCallUnderwater
+		}
+	}
+	if !rules.Enabled(rule) {
+		return
+	}
+
+	annualized := CalculateAnnualizedReturn(extrinsic, basis, days)
+	minReturn := rules.Threshold(rule)
+	status := CheckPass
+	if annualized < minReturn {
+		status = CheckFail
+	}
+	add(rule, status, "%.0f%% annualized on %s $%.2f over %dd (min %.0f%%)", annualized, basisLabel, basis, days, minReturn)
+}
+
+// limitStatus fails past a percentage limit and warns when close to it
+func limitStatus(value, limit float64) string {
+	switch {
+	case value > limit:
+		return CheckFail
+	case value > limit*checkWarnFraction:
+		return CheckWarn
+	default:
+		return CheckPass
+	}
+}
+
+func statusRank(status string) int {
+	switch status {
+	case CheckFail:
+		return 2
+	case CheckWarn:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// openCallContracts returns the call contracts already written on a symbol
+func openCallContracts(symbol string) int {
+	return openCalls()[symbol]
+}
+
+// openCalls returns the call contracts already written, by symbol
+func openCalls() map[string]int {
+	positions := web.CalculateOptionPositions(web.LoadOptionTransactions("data/options_transactions.csv"))
+
+	contracts := make(map[string]int)
+	for _, pos := range positions {
+		if pos.Status == "Open" && pos.OptionType == "Call" {
+			contracts[pos.Symbol] += pos.Contracts
+		}
+	}
+	return contracts
+}
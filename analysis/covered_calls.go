@@ -0,0 +1,326 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// Covered-call return thresholds from rule 12
+const (
+	DefaultCoveredCallMinReturn           = 100.0 // Annualized % on cost basis when the lot is above water
+	DefaultCoveredCallMinReturnUnderwater = 50.0  // Annualized % on cost basis when the lot is underwater
+)
+
+// CoveredCallParams defines parameters for scanning covered calls on stock we hold.
+// Strike, expiry, liquidity and commission settings come from the embedded BatchScanParams;
+// its SolarSystemCSV, Right and MinReturn are not used.
+type CoveredCallParams struct {
+	BatchScanParams
+	StockTransactionsCSV string  // Path to stocks_transactions.csv
+	MinReturn            float64 // Minimum annualized % on cost basis above water (0 = DefaultCoveredCallMinReturn)
+	MinReturnUnderwater  float64 // Minimum annualized % on cost basis underwater (0 = DefaultCoveredCallMinReturnUnderwater)
+}
+
+// Holding is an open stock position large enough to write covered calls against
+type Holding struct {
+	Symbol    string
+	Shares    float64
+	CostBasis float64 // Per share, including commissions
+	Contracts int     // Covered calls the shares can still secure (100 shares each, less calls already written)
+}
+
+// CoveredCall is a call contract evaluated against our cost basis
+type CoveredCall struct {
+	OptionContract
+	Holding Holding
+
+	Underwater        bool    // Stock is trading below our cost basis
+	ReturnOnCostBasis float64 // Annualized % of net premium on cost basis
+	MinReturn         float64 // Threshold applied (50% underwater, 100% above water)
+	BelowCostBasis    bool    // Assignment would realize a loss on the shares
+	AssignmentPnL     float64 // Realized P&L per contract if assigned: shares at strike vs cost basis, plus full premium net of fees
+}
+
+// LoadHoldings returns open stock positions with at least 100 shares not already covered by
+// written calls (rule 2: every call is covered)
+func LoadHoldings(stockTransactionsCSV string) []Holding {
+	transactions := web.LoadStockTransactions(stockTransactionsCSV)
+	positions := web.CalculateAllPositions(transactions, map[string]float64{})
+	written := openCalls()
+
+	var holdings []Holding
+	for _, pos := range positions {
+		if pos.Type != "open" {
+			continue
+		}
+		free := int(math.Floor(pos.Shares/100)) - written[pos.Symbol]
+		if free <= 0 {
+			continue
+		}
+		holdings = append(holdings, Holding{
+			Symbol:    pos.Symbol,
+			Shares:    pos.Shares,
+			CostBasis: pos.CostBasis / pos.Shares,
+			Contracts: free,
+		})
+	}
+
+	sort.Slice(holdings, func(i, j int) bool {
+		return holdings[i].Symbol < holdings[j].Symbol
+	})
+
+	return holdings
+}
+
+// evaluateCoveredCalls scores scanned calls against a holding and keeps those meeting the threshold
+func evaluateCoveredCalls(holding Holding, result *SymbolScan, params CoveredCallParams) []CoveredCall {
+	minReturn := params.MinReturn
+	if minReturn <= 0 {
+		minReturn = DefaultCoveredCallMinReturn
+	}
+	underwater := result.Price < holding.CostBasis
+	if underwater {
+		minReturn = params.MinReturnUnderwater
+		if minReturn <= 0 {
+			minReturn = DefaultCoveredCallMinReturnUnderwater
+		}
+	}
+
+	var calls []CoveredCall
+	for _, contract := range result.Contracts {
+		returnOnCostBasis := CalculateAnnualizedReturn(contract.NetPremium/100, holding.CostBasis, int(math.Max(1, float64(contract.DTE))))
+		if returnOnCostBasis < minReturn {
+			continue
+		}
+
+		calls = append(calls, CoveredCall{
+			OptionContract:    contract,
+			Holding:           holding,
+			Underwater:        underwater,
+			ReturnOnCostBasis: returnOnCostBasis,
+			MinReturn:         minReturn,
+			BelowCostBasis:    contract.Strike < holding.CostBasis,
+			AssignmentPnL:     (contract.Strike-holding.CostBasis)*100 + contract.Premium - contract.Commission,
+		})
+	}
+
+	sort.SliceStable(calls, func(i, j int) bool {
+		// Calls that keep the shares' cost basis intact rank first
+		if calls[i].BelowCostBasis != calls[j].BelowCostBasis {
+			return !calls[i].BelowCostBasis
+		}
+		return calls[i].ReturnOnCostBasis > calls[j].ReturnOnCostBasis
+	})
+
+	return calls
+}
+
+// ScanCoveredCalls scans calls on every holding with 100 shares free of written calls and saves
+// them to Output
+func (s *Scanner) ScanCoveredCalls(params CoveredCallParams) error {
+	start := time.Now()
+	holdings := LoadHoldings(params.StockTransactionsCSV)
+	if len(holdings) == 0 {
+		return fmt.Errorf("no holdings of 100+ shares free of written calls in %s", params.StockTransactionsCSV)
+	}
+
+	workers := params.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	fmt.Printf("📞 Scanning covered calls on %d holdings\n", len(holdings))
+	fmt.Printf("   Min Return on cost basis: %.0f%% (underwater: %.0f%%), Expiries: %d, Workers: %d\n\n",
+		orDefault(params.MinReturn, DefaultCoveredCallMinReturn),
+		orDefault(params.MinReturnUnderwater, DefaultCoveredCallMinReturnUnderwater),
+		params.NumExpiries, workers)
+
+	// Scan calls with no return filter; thresholds apply to cost basis afterwards
+	batch := params.BatchScanParams
+	batch.Right = "C"
+	batch.MinReturn = 0
+
+	results := make([][]CoveredCall, len(holdings))
+	scans := make([]*SymbolScan, len(holdings))
+	completed := 0
+	failedStocks := []string{}
+	var mu sync.Mutex
+
+	forEach(len(holdings), workers, func(i int) {
+		holding := holdings[i]
+		// Reach the cost basis strike even when an underwater lot's basis is outside the band
+		scanParams := batch.scanParams(holding.Symbol)
+		scanParams.ReachStrike = holding.CostBasis
+		result, err := s.scanSymbol(scanParams)
+		scans[i] = result
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		completed++
+		fmt.Printf("[%d/%d] %s (%.0f shares @ $%.2f)\n", completed, len(holdings), holding.Symbol, holding.Shares, holding.CostBasis)
+
+		if err != nil {
+			fmt.Printf("   ❌ Error: %v\n\n", err)
+			failedStocks = append(failedStocks, fmt.Sprintf("%s: %v", holding.Symbol, err))
+			return
+		}
+
+		results[i] = evaluateCoveredCalls(holding, result, params)
+
+		status := "above water"
+		if result.Price < holding.CostBasis {
+			status = "underwater"
+		}
+		fmt.Printf("   Price: $%.2f (%s)\n", result.Price, status)
+		if params.Explain {
+			PrintExplain(result)
+		}
+		for _, c := range results[i] {
+			flag := ""
+			if c.BelowCostBasis {
+				flag = fmt.Sprintf(" ⚠️  below cost basis, $%.0f if assigned", c.AssignmentPnL)
+			}
+			fmt.Printf("      $%.2f (%dd): $%.0f → %.0f%% on cost basis%s\n",
+				c.Strike, c.DTE, c.NetPremium, c.ReturnOnCostBasis, flag)
+		}
+		fmt.Printf("   ✅ Found %d covered calls\n\n", len(results[i]))
+	})
+
+	var calls []CoveredCall
+	for _, r := range results {
+		calls = append(calls, r...)
+	}
+
+	if err := writeCoveredCalls(calls, params.OutputFormat, params.Output); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+
+	// Summary
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("✨ Covered Call Scan Complete!\n")
+	fmt.Printf("   Holdings: %d\n", len(holdings))
+	fmt.Printf("   Total Contracts: %d\n", len(calls))
+	fmt.Printf("   Saved to: %s\n", params.Output)
+
+	symbols := make([]string, len(holdings))
+	for i, holding := range holdings {
+		symbols[i] = holding.Symbol
+	}
+	contracts := make([]OptionContract, len(calls))
+	for i, call := range calls {
+		contracts[i] = call.OptionContract
+	}
+	if !isReplay(s.provider) {
+		batch.saveHistory(ScanKindCoveredCalls, start, symbols, contracts)
+		batch.recordIV(scans)
+		batch.recordVolSurfaces(scans)
+	}
+
+	if len(failedStocks) > 0 {
+		fmt.Printf("\n❌ Failed stocks:\n")
+		for _, failure := range failedStocks {
+			fmt.Printf("   %s\n", failure)
+		}
+	}
+
+	return nil
+}
+
+// CoveredCallSchema is the schema of covered-call scans: each call with its cost-basis metrics
+var CoveredCallSchema = ResultSchema{
+	Table: "covered_calls",
+	Columns: []Column{
+		{"Symbol", ColumnText, 0},
+		{"Shares", ColumnReal, 0},
+		{"Contracts", ColumnInt, 0},
+		{"CostBasis", ColumnReal, 2},
+		{"UnderlyingPrice", ColumnReal, 2},
+		{"Underwater", ColumnBool, 0},
+		{"Strike", ColumnReal, 2},
+		{"MaturityDate", ColumnText, 0},
+		{"DTE", ColumnInt, 0},
+		{"Bid", ColumnReal, 2},
+		{"Ask", ColumnReal, 2},
+		{"FillPrice", ColumnReal, 2},
+		{"ExtrinsicValue", ColumnReal, 2},
+		{"NetPremium", ColumnReal, 2},
+		{"AnnualizedReturn", ColumnReal, 2},
+		{"ReturnOnCostBasis", ColumnReal, 2},
+		{"MinReturn", ColumnReal, 0},
+		{"BelowCostBasis", ColumnBool, 0},
+		{"AssignmentPnL", ColumnReal, 2},
+		{"POP", ColumnReal, 2},
+		{"ProbAssignment", ColumnReal, 2},
+		{"Breakeven", ColumnReal, 2},
+		{"BreakevenMoves", ColumnReal, 2},
+		{"Delta", ColumnReal, 4},
+		{"SpreadPercent", ColumnReal, 1},
+		{"OpenInterest", ColumnInt, 0},
+		{"ConID", ColumnInt, 0},
+	},
+}
+
+// CoveredCallRecord returns the CoveredCallSchema values of a covered call
+func CoveredCallRecord(c CoveredCall) Record {
+	return Record{
+		c.Symbol,
+		c.Holding.Shares,
+		c.Holding.Contracts,
+		c.Holding.CostBasis,
+		c.UnderlyingPrice,
+		c.Underwater,
+		c.Strike,
+		c.MaturityDate,
+		c.DTE,
+		c.Bid,
+		c.Ask,
+		c.FillPrice,
+		c.ExtrinsicValue,
+		c.NetPremium,
+		c.AnnualizedReturn,
+		c.ReturnOnCostBasis,
+		c.MinReturn,
+		c.BelowCostBasis,
+		c.AssignmentPnL,
+		c.POP,
+		c.ProbAssignment,
+		c.Breakeven,
+		c.BreakevenMoves,
+		c.Delta,
+		c.SpreadPercent,
+		c.OpenInterest,
+		c.ConID,
+	}
+}
+
+// writeCoveredCalls writes covered-call candidates with their cost-basis metrics
+func writeCoveredCalls(calls []CoveredCall, format, path string) error {
+	writer, err := OpenResultWriter(format, path)
+	if err != nil {
+		return err
+	}
+	if err := writer.Begin(CoveredCallSchema); err != nil {
+		writer.Close()
+		return err
+	}
+	for _, c := range calls {
+		if err := writer.Write(CoveredCallRecord(c)); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func orDefault(value, fallback float64) float64 {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"mnmlsm/web"
 )
@@ -15,13 +16,14 @@ type StockCandidate struct {
 	Name                  string
 	Price                 float64
 	Sector                string
-	PositionCost          float64 // Cost for 100 shares
-	PositionSizePercent   float64 // % of total net worth after adding
-	SectorExposure        float64 // Total sector capital after adding
-	SectorPercent         float64 // % of total net worth in sector after adding
-	ExistingStockPosition bool    // Do we already hold stock?
-	ExistingPutPosition   bool    // Do we already have a cash-secured put?
-	ExistingCapital       float64 // Current capital deployed in this symbol
+	PositionCost          float64     // Cost for 100 shares
+	PositionSizePercent   float64     // % of total net worth after adding
+	SectorExposure        float64     // Total sector capital after adding
+	SectorPercent         float64     // % of total net worth in sector after adding
+	ExistingStockPosition bool        // Do we already hold stock?
+	ExistingPutPosition   bool        // Do we already have a cash-secured put?
+	ExistingCapital       float64     // Current capital deployed in this symbol
+	IV                    web.IVStats // Latest recorded ATM IV and its rank (no Windows = no history)
 }
 
 // EliminationResult contains the filtering results
@@ -30,87 +32,118 @@ type EliminationResult struct {
 	Eliminated    map[string]string // symbol -> reason
 	TotalNetWorth float64
 	DryPowder     float64
+	Rules         web.TradingRules // Rules config the filters were run with
+
+	// Cash reserve (rules 5 and 14)
+	VIX            float64
+	VIXRegime      string  // Regime of the VIX tier in effect ("" if VIX deployment is off)
+	ReservePercent float64 // % of net worth kept in cash
+	Reserve        float64 // Cash kept in reserve
+	Deployable     float64 // Dry powder above the reserve
 }
 
-// RunElimination filters universe.csv through 3 criteria and outputs solar-system.csv
+// RunElimination filters universe.csv through the enabled rules in data/rules.json and outputs solar-system.csv
 func RunElimination() (*EliminationResult, error) {
-	// 1. Calculate total net worth
-	totalNetWorth, err := calculateTotalNetWorth()
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate total net worth: %w", err)
-	}
+	rules := web.LoadTradingRules("data/rules.json")
 
-	// 2. Get dry powder (available capital)
-	dryPowder, err := getDryPowder()
+	// 1. Load net worth, dry powder and the cash reserve
+	portfolio, err := LoadPortfolioSnapshot(rules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get dry powder: %w", err)
+		return nil, err
 	}
+	totalNetWorth := portfolio.NetWorth
+	dryPowder := portfolio.DryPowder
 
-	// 3. Get current positions (stocks + puts)
-	stockPositions := getCurrentStockPositions()
-	putPositions := getCurrentPutPositions()
-
-	// Combine positions by symbol
-	existingCapitalBySymbol := make(map[string]float64)
+	// 2. Get current positions (stocks + puts)
 	hasStockPosition := make(map[string]bool)
 	hasPutPosition := make(map[string]bool)
-
-	for symbol, capital := range stockPositions {
-		existingCapitalBySymbol[symbol] += capital
+	for symbol := range portfolio.StockCapital {
 		hasStockPosition[symbol] = true
 	}
-
-	for symbol, capital := range putPositions {
-		existingCapitalBySymbol[symbol] += capital
+	for symbol := range portfolio.PutCapital {
 		hasPutPosition[symbol] = true
 	}
 
-	// 4. Get current sector exposure
+	// 3. Get current sector exposure
 	sectorExposure := getCurrentSectorExposure()
 
-	// 5. Load universe
+	// 4. Load event calendar for the earnings check
+	events := web.LoadEvents("data/events.csv")
+	now := time.Now()
+	earningsDays := int(rules.Threshold(web.RuleNoEarnings))
+	earningsCutoff := now.AddDate(0, 0, earningsDays)
+
+	// 5. Load the IV history for IV rank (informational, not a filter)
+	ivHistory := web.LoadIVHistory(DefaultIVHistoryCSV)
+
+	// 6. Load universe
 	universe, err := loadUniverse()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load universe: %w", err)
 	}
 
-	// 6. Filter each stock
+	// 7. Filter each stock
 	result := &EliminationResult{
 		Survivors:     []StockCandidate{},
 		Eliminated:    make(map[string]string),
 		TotalNetWorth: totalNetWorth,
 		DryPowder:     dryPowder,
+		Rules:         rules,
+
+		VIX:            portfolio.VIX,
+		VIXRegime:      portfolio.VIXRegime,
+		ReservePercent: portfolio.ReservePercent,
+		Reserve:        portfolio.Reserve,
+		Deployable:     portfolio.Deployable,
 	}
 
+	maxPosition := rules.Threshold(web.RuleMaxPosition)
+	maxSector := rules.Threshold(web.RuleMaxSector)
+
 	for _, stock := range universe {
 		positionCost := stock.Price * 100 // Cost for 100 shares
-		existingCapital := existingCapitalBySymbol[stock.Symbol]
+		existingCapital := portfolio.ExistingCapital(stock.Symbol)
 
-		// Filter #1: Available Capital Check
+		// Filter #1: Available Capital Check (no margin)
 		if positionCost > dryPowder {
 			result.Eliminated[stock.Symbol] = fmt.Sprintf("Insufficient capital: need $%.0f, have $%.0f", positionCost, dryPowder)
 			continue
 		}
 
-		// Filter #2: Position Size Limit (10% max)
+		// Filter #2: Cash Reserve (cash minimum, raised when the VIX is high)
+		if positionCost > portfolio.Deployable {
+			result.Eliminated[stock.Symbol] = fmt.Sprintf("Breaks cash reserve: need $%.0f, $%.0f deployable above $%.0f reserve (%.0f%%)",
+				positionCost, portfolio.Deployable, portfolio.Reserve, portfolio.ReservePercent)
+			continue
+		}
+
+		// Filter #3: Position Size Limit
 		newTotalCapital := existingCapital + positionCost
 		positionPercent := (newTotalCapital / totalNetWorth) * 100
 
-		if positionPercent > 10.0 {
-			result.Eliminated[stock.Symbol] = fmt.Sprintf("Position too large: %.1f%% of net worth (max 10%%)", positionPercent)
+		if rules.Enabled(web.RuleMaxPosition) && positionPercent > maxPosition {
+			result.Eliminated[stock.Symbol] = fmt.Sprintf("Position too large: %.1f%% of net worth (max %.0f%%)", positionPercent, maxPosition)
 			continue
 		}
 
-		// Filter #3: Sector Concentration (20% max)
+		// Filter #4: Sector Concentration
 		currentSectorCapital := sectorExposure[stock.Sector]
 		newSectorCapital := currentSectorCapital + positionCost
 		sectorPercent := (newSectorCapital / totalNetWorth) * 100
 
-		if sectorPercent > 20.0 {
-			result.Eliminated[stock.Symbol] = fmt.Sprintf("Sector too concentrated: %.1f%% in %s (max 20%%)", sectorPercent, stock.Sector)
+		if rules.Enabled(web.RuleMaxSector) && sectorPercent > maxSector {
+			result.Eliminated[stock.Symbol] = fmt.Sprintf("Sector too concentrated: %.1f%% in %s (max %.0f%%)", sectorPercent, stock.Sector, maxSector)
 			continue
 		}
 
+		// Filter #5: No trading around earnings
+		if rules.Enabled(web.RuleNoEarnings) {
+			if event, ok := web.NextEarnings(events, stock.Symbol, now, earningsCutoff); ok {
+				result.Eliminated[stock.Symbol] = fmt.Sprintf("Earnings on %s (within %d days)", event.Date, earningsDays)
+				continue
+			}
+		}
+
 		// Stock passed all filters!
 		candidate := StockCandidate{
 			Symbol:                stock.Symbol,
@@ -125,11 +158,12 @@ func RunElimination() (*EliminationResult, error) {
 			ExistingPutPosition:   hasPutPosition[stock.Symbol],
 			ExistingCapital:       existingCapital,
 		}
+		candidate.IV, _ = web.CalculateIVStats(ivHistory, stock.Symbol)
 
 		result.Survivors = append(result.Survivors, candidate)
 	}
 
-	// 7. Write solar-system.csv
+	// 8. Write solar-system.csv
 	if err := writeSolarSystemCSV(result.Survivors); err != nil {
 		return nil, fmt.Errorf("failed to write solar-system.csv: %w", err)
 	}
@@ -145,40 +179,20 @@ type UniverseStock struct {
 	Sector string
 }
 
-// loadUniverse loads stocks from data/universe.csv
+// loadUniverse loads the priced stocks from data/universe.csv
 func loadUniverse() ([]UniverseStock, error) {
-	file, err := os.Open("data/universe.csv")
+	stocks, err := readUniverse()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	var stocks []UniverseStock
-	for i, record := range records {
-		if i == 0 || len(record) < 4 {
-			continue // Skip header
-		}
-
-		price, err := strconv.ParseFloat(record[2], 64)
-		if err != nil {
-			continue
+	priced := stocks[:0]
+	for _, stock := range stocks {
+		if stock.Price > 0 {
+			priced = append(priced, stock)
 		}
-
-		stocks = append(stocks, UniverseStock{
-			Symbol: record[0],
-			Name:   record[1],
-			Price:  price,
-			Sector: record[3],
-		})
 	}
-
-	return stocks, nil
+	return priced, nil
 }
 
 // calculateTotalNetWorth returns portfolio value + Wise balance
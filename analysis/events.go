@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// Earnings handling for ScanParams.EarningsMode (rule 3: no trading around earnings)
+const (
+	EarningsIgnore  = "ignore"  // Don't check the event calendar (default)
+	EarningsFlag    = "flag"    // Keep contracts spanning earnings but set EarningsDate
+	EarningsExclude = "exclude" // Drop contracts whose life spans an earnings date
+)
+
+// earningsSelected sets the contract's EarningsDate if an earnings release falls between
+// params.AsOf and expiry, and reports whether the contract survives the earnings mode
+func earningsSelected(params ScanParams, contract *OptionContract) bool {
+	if params.EarningsMode == "" || params.EarningsMode == EarningsIgnore {
+		return true
+	}
+
+	expiry, err := time.Parse("20060102", contract.MaturityDate)
+	if err != nil {
+		return true
+	}
+
+	event, ok := web.NextEarnings(params.Events, params.Symbol, params.AsOf, expiry)
+	if !ok {
+		return true
+	}
+
+	contract.EarningsDate = event.Date
+	return params.EarningsMode != EarningsExclude
+}
+
+// EventImport is the outcome of importing upcoming events from a provider
+type EventImport struct {
+	Events []web.Event
+	Failed map[string]string // symbol -> error
+}
+
+// ImportEvents reads the next earnings release and ex-dividend date of every symbol (all of
+// data/universe.csv when none are given) from the provider's event calendar
+func ImportEvents(provider MarketDataProvider, symbols []string, exchange string, workers int) (*EventImport, error) {
+	calendar, ok := provider.(EventProvider)
+	if !ok {
+		return nil, fmt.Errorf("market data provider has no event calendar")
+	}
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	if len(symbols) == 0 {
+		stocks, err := loadUniverse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load universe: %w", err)
+		}
+		for _, stock := range stocks {
+			symbols = append(symbols, stock.Symbol)
+		}
+	}
+
+	result := &EventImport{Failed: make(map[string]string)}
+	var mu sync.Mutex
+
+	forEach(len(symbols), workers, func(i int) {
+		symbol := symbols[i]
+		events, err := upcomingEvents(provider, calendar, symbol, exchange)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			result.Failed[symbol] = err.Error()
+			return
+		}
+		result.Events = append(result.Events, events...)
+	})
+
+	return result, nil
+}
+
+// upcomingEvents resolves a symbol and converts its upcoming events to calendar entries
+func upcomingEvents(provider MarketDataProvider, calendar EventProvider, symbol, exchange string) ([]web.Event, error) {
+	conID, err := provider.LookupUnderlying(symbol, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("searching underlying: %w", err)
+	}
+
+	upcoming, err := calendar.UpcomingEvents(conID)
+	if err != nil {
+		return nil, fmt.Errorf("getting events: %w", err)
+	}
+
+	var events []web.Event
+	for _, e := range []struct{ kind, value string }{
+		{web.EventEarnings, upcoming.Earnings},
+		{web.EventDividend, upcoming.ExDividend},
+	} {
+		if e.value == "" {
+			continue
+		}
+		date, err := parseGatewayDate(e.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s date %q: %w", strings.ToLower(e.kind), e.value, err)
+		}
+		events = append(events, web.Event{
+			Symbol: symbol,
+			Date:   date.Format("2006-01-02"),
+			Type:   e.kind,
+			Notes:  "Imported from IBKR",
+		})
+	}
+	return events, nil
+}
+
+// parseGatewayDate parses an event date in one of the formats the gateway reports
+func parseGatewayDate(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "2006-01-02", "01/02/2006", "Jan 2, 2006", "Jan 2 2006", "02-Jan-2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format")
+}
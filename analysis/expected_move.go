@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExpiryMove is the market-implied move of the stock by one expiry
+type ExpiryMove struct {
+	MaturityDate string // 20060102
+	DTE          int
+	ATMIV        float64 // IV of the scanned strike nearest the price
+	ImpliedMove  float64 // One standard deviation move implied by ATMIV, per share (0 = no IV)
+	Straddle     float64 // Mid of the at-the-money call plus put, per share (0 = not quoted)
+}
+
+// ImpliedMove returns the one standard deviation move of a stock by expiry implied by an IV:
+// price × IV × √(DTE/365)
+func ImpliedMove(price, iv float64, dte int) float64 {
+	vol := NormalizeIV(iv)
+	if vol <= 0 || price <= 0 {
+		return 0
+	}
+	return price * vol * math.Sqrt(yearsToExpiry(dte))
+}
+
+// Breakeven returns the stock price at expiry where a short option stops being profitable:
+// strike − premium for puts, strike + premium for calls (premium per share)
+func Breakeven(right string, strike, premium float64) float64 {
+	if right == "P" {
+		return strike - premium
+	}
+	return strike + premium
+}
+
+// fetchStraddleLegs quotes the call and put at the strike nearest the price in each month, with
+// DTEs counted from now
+func fetchStraddleLegs(provider MarketDataProvider, conID int, price float64, months []string, strikes []float64, now time.Time, workers int) []ivQuote {
+	type legJob struct {
+		month  string
+		strike float64
+		right  string
+	}
+	var jobs []legJob
+	for i, month := range months {
+		if strikes[i] <= 0 {
+			continue
+		}
+		jobs = append(jobs, legJob{month, strikes[i], "C"}, legJob{month, strikes[i], "P"})
+	}
+
+	var mu sync.Mutex
+	var legs []ivQuote
+	forEach(len(jobs), workers, func(i int) {
+		job := jobs[i]
+		contracts, err := provider.Contracts(conID, job.month, job.strike, job.right)
+		if err != nil {
+			return
+		}
+		for _, contract := range contracts {
+			pricing, err := provider.OptionQuote(contract.ConID)
+			if err != nil || (pricing.Bid <= 0 && pricing.Ask <= 0) {
+				continue
+			}
+			dte := daysToExpiry(contract.MaturityDate, now)
+			delta := pricing.Delta
+			if delta == 0 {
+				delta = ModelDelta(job.right, price, job.strike, pricing.ImpliedVol, dte)
+			}
+
+			mu.Lock()
+			legs = append(legs, ivQuote{
+				strike: job.strike, dte: dte, iv: NormalizeIV(pricing.ImpliedVol),
+				right: job.right, delta: delta, mid: NewQuote(pricing.Bid, pricing.Ask).Mid, maturity: contract.MaturityDate,
+			})
+			mu.Unlock()
+		}
+	})
+	return legs
+}
+
+// straddleMids returns the call plus put mid of each maturity's straddle legs. Maturities
+// missing either side are left out.
+func straddleMids(legs []ivQuote) map[string]float64 {
+	calls := make(map[string]float64)
+	puts := make(map[string]float64)
+	for _, leg := range legs {
+		if leg.right == "C" {
+			calls[leg.maturity] = leg.mid
+		} else {
+			puts[leg.maturity] = leg.mid
+		}
+	}
+
+	straddles := make(map[string]float64)
+	for maturity, call := range calls {
+		if put, ok := puts[maturity]; ok {
+			straddles[maturity] = call + put
+		}
+	}
+	return straddles
+}
+
+// nearestStrike returns the listed strike closest to the price, or 0 if none are listed
+func nearestStrike(strikes []float64, price float64) float64 {
+	nearest := 0.0
+	for _, strike := range strikes {
+		if nearest == 0 || math.Abs(strike-price) < math.Abs(nearest-price) {
+			nearest = strike
+		}
+	}
+	return nearest
+}
+
+// expiryMoves builds the expected move of every scanned maturity from the ATM IV of its quotes
+// and its straddle, nearest first
+func expiryMoves(quotes []ivQuote, maturities map[int]string, straddles map[string]float64, price float64) []ExpiryMove {
+	var moves []ExpiryMove
+	for dte, maturity := range maturities {
+		iv := expiryATMIV(quotes, price, dte)
+		moves = append(moves, ExpiryMove{
+			MaturityDate: maturity,
+			DTE:          dte,
+			ATMIV:        iv,
+			ImpliedMove:  ImpliedMove(price, iv, dte),
+			Straddle:     straddles[maturity],
+		})
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].DTE < moves[j].DTE
+	})
+	return moves
+}
+
+// applyExpectedMove sets a contract's expected move by its expiry and how many of them
+// its breakeven sits from the price. The contract's own IV stands in when its expiry has
+// no ATM IV.
+func applyExpectedMove(contract *OptionContract, moves []ExpiryMove) {
+	contract.ExpectedMove = ImpliedMove(contract.UnderlyingPrice, contract.ImpliedVol, contract.DTE)
+	for _, move := range moves {
+		if move.MaturityDate != contract.MaturityDate {
+			continue
+		}
+		if move.ImpliedMove > 0 {
+			contract.ExpectedMove = move.ImpliedMove
+		}
+		contract.ExpectedMoveStraddle = move.Straddle
+	}
+
+	if contract.ExpectedMove > 0 {
+		distance := contract.UnderlyingPrice - contract.Breakeven
+		if contract.Right != "P" {
+			distance = -distance
+		}
+		contract.BreakevenMoves = distance / contract.ExpectedMove
+	}
+}
+
+// FormatExpiryMoves formats each maturity as "Oct 24 ±$1.20, straddle $1.05" (separated by "; ") for
+// progress output
+func FormatExpiryMoves(moves []ExpiryMove) string {
+	var parts []string
+	for _, move := range moves {
+		if move.ImpliedMove <= 0 && move.Straddle <= 0 {
+			continue
+		}
+		label := move.MaturityDate
+		if date, err := time.Parse("20060102", move.MaturityDate); err == nil {
+			label = date.Format("Jan 2")
+		}
+		var sources []string
+		if move.ImpliedMove > 0 {
+			sources = append(sources, fmt.Sprintf("±$%.2f", move.ImpliedMove))
+		}
+		if move.Straddle > 0 {
+			sources = append(sources, fmt.Sprintf("straddle $%.2f", move.Straddle))
+		}
+		parts = append(parts, label+" "+strings.Join(sources, ", "))
+	}
+	if len(parts) == 0 {
+		return "n/a"
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scan stages a strike or contract can be rejected at, in pipeline order
+const (
+	StageStrike   = "strike"   // Strike lookup and selection around the current price
+	StageContract = "contract" // Contract lookup and DTE limits
+	StagePricing  = "pricing"  // Option quote
+	StageFilter   = "filter"   // Return, delta, liquidity and earnings filters
+)
+
+// Rejection records why a strike or contract didn't make it into the scan results
+type Rejection struct {
+	Month        string
+	Strike       float64 // 0 when a whole month failed
+	ConID        int     // 0 before contracts are resolved
+	MaturityDate string
+	Stage        string
+	Reason       string // Category, e.g. "return below minimum"
+	Detail       string // Specifics, e.g. "62% < 100%"
+}
+
+// ExpiryFunnel counts what survived each stage of the scan for one option month
+type ExpiryFunnel struct {
+	Month     string
+	Strikes   int // Listed strikes
+	InRange   int // Strikes selected by the strike mode
+	Contracts int // Contracts within the DTE limits
+	Priced    int // Contracts with a usable quote
+	Passed    int // Contracts that passed every filter
+}
+
+// ScanSymbol runs the scan for one symbol and returns the qualifying contracts together with
+// the funnel and rejections of every strike and contract
+func (s *Scanner) ScanSymbol(params ScanParams) (*SymbolScan, error) {
+	return s.scanSymbol(params)
+}
+
+// filterRejection applies the return, delta, liquidity and earnings filters in order and
+// returns why the contract fails, or "" if it passes
+func filterRejection(params ScanParams, contract *OptionContract) (reason, detail string) {
+	if contract.AnnualizedReturn < params.MinReturn {
+		return "return below minimum", fmt.Sprintf("%.0f%% < %.0f%%", contract.AnnualizedReturn, params.MinReturn)
+	}
+	if reason, detail := deltaRejection(params, contract.Delta); reason != "" {
+		return reason, detail
+	}
+	if reason, detail := liquidityRejection(params, *contract); reason != "" {
+		return reason, detail
+	}
+	if !earningsSelected(params, contract) {
+		return "earnings before expiry", contract.EarningsDate
+	}
+	return "", ""
+}
+
+// PrintExplain prints the funnel of each scanned month with its rejections grouped by reason.
+// Strike-level rejections are counted; contract-level ones are listed.
+func PrintExplain(result *SymbolScan) {
+	byMonth := make(map[string][]Rejection)
+	for _, r := range result.Rejections {
+		byMonth[r.Month] = append(byMonth[r.Month], r)
+	}
+
+	for _, f := range result.Funnels {
+		fmt.Printf("   🔎 %s: %d strikes → %d in range → %d contracts → %d priced → %d passed\n",
+			f.Month, f.Strikes, f.InRange, f.Contracts, f.Priced, f.Passed)
+
+		for _, group := range groupRejections(byMonth[f.Month]) {
+			first := group[0]
+			if first.Stage == StageStrike || (first.Stage == StageContract && first.ConID == 0) {
+				fmt.Printf("      %3d × %s: %s%s\n", len(group), first.Stage, first.Reason, summarizeDetails(group))
+				continue
+			}
+
+			fmt.Printf("      %3d × %s: %s\n", len(group), first.Stage, first.Reason)
+			for _, r := range group {
+				detail := ""
+				if r.Detail != "" {
+					detail = " — " + r.Detail
+				}
+				fmt.Printf("            $%.2f %s%s\n", r.Strike, r.MaturityDate, detail)
+			}
+		}
+	}
+}
+
+// groupRejections groups rejections by stage and reason, in pipeline order then by count
+func groupRejections(rejections []Rejection) [][]Rejection {
+	groups := make(map[string][]Rejection)
+	var keys []string
+	for _, r := range rejections {
+		key := r.Stage + "\x00" + r.Reason
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], r)
+	}
+
+	stageOrder := map[string]int{StageStrike: 0, StageContract: 1, StagePricing: 2, StageFilter: 3}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := groups[keys[i]][0], groups[keys[j]][0]
+		if stageOrder[a.Stage] != stageOrder[b.Stage] {
+			return stageOrder[a.Stage] < stageOrder[b.Stage]
+		}
+		return len(groups[keys[i]]) > len(groups[keys[j]])
+	})
+
+	result := make([][]Rejection, len(keys))
+	for i, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			if group[i].MaturityDate != group[j].MaturityDate {
+				return group[i].MaturityDate < group[j].MaturityDate
+			}
+			return group[i].Strike < group[j].Strike
+		})
+		result[i] = group
+	}
+	return result
+}
+
+// summarizeDetails returns " (detail)" when a group shares one detail, or the first few otherwise
+func summarizeDetails(group []Rejection) string {
+	seen := make(map[string]bool)
+	var details []string
+	for _, r := range group {
+		if r.Detail != "" && !seen[r.Detail] {
+			seen[r.Detail] = true
+			details = append(details, r.Detail)
+		}
+	}
+
+	switch {
+	case len(details) == 0:
+		return ""
+	case len(details) > 3:
+		return fmt.Sprintf(" (%s, …)", strings.Join(details[:3], "; "))
+	default:
+		return fmt.Sprintf(" (%s)", strings.Join(details, "; "))
+	}
+}
@@ -0,0 +1,421 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultScanHistoryDir is where scan runs are kept, one JSON file per run
+const DefaultScanHistoryDir = "data/scans"
+
+// Scan run kinds
+const (
+	ScanKindSolarSystem  = "solar-system"  // scan-all over solar-system.csv (also the pipeline)
+	ScanKindCoveredCalls = "covered-calls" // scan-all over holdings
+	ScanKindSymbol       = "symbol"        // ibkr-quote premium scan of one symbol, saved as symbol-<SYMBOL>
+)
+
+// SymbolScanKind returns the kind single-symbol runs of a symbol are saved as, e.g. "symbol-AAPL",
+// so runs of different tickers aren't compared
+func SymbolScanKind(symbol string) string {
+	return ScanKindSymbol + "-" + strings.ToUpper(symbol)
+}
+
+// scanRunIDFormat starts run IDs with their start time, so IDs sort chronologically.
+// The kind follows, e.g. "20261018-143005-solar-system".
+const scanRunIDFormat = "20060102-150405"
+
+// ScanRun is one saved scan: when it ran, with what settings, and every contract that qualified
+type ScanRun struct {
+	ID        string           `json:"id"`
+	Time      time.Time        `json:"time"`
+	Kind      string           `json:"kind"`
+	Params    ScanRunParams    `json:"params"`
+	Contracts []OptionContract `json:"contracts"`
+}
+
+// ScanRunParams are the scan settings recorded with a run
+type ScanRunParams struct {
+	Symbols          []string `json:"symbols"`
+	Right            string   `json:"right"`
+	MinReturn        float64  `json:"minReturn"`
+	StrikeMode       string   `json:"strikeMode"`
+	StrikeRange      float64  `json:"strikeRange"`
+	MinDelta         float64  `json:"minDelta"`
+	MaxDelta         float64  `json:"maxDelta"`
+	NumExpiries      int      `json:"numExpiries"`
+	MaxDTE           int      `json:"maxDte"`
+	MinOpenInterest  int      `json:"minOpenInterest"`
+	MinVolume        int      `json:"minVolume"`
+	MaxSpreadPercent float64  `json:"maxSpreadPercent"`
+	FillMode         string   `json:"fill"`
+	EarningsMode     string   `json:"earnings"`
+	Sized            bool     `json:"sized"`
+	ScoreWeights     string   `json:"scoreWeights,omitempty"`
+}
+
+// ScanRunInfo summarizes a saved run without its contracts
+type ScanRunInfo struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Right     string    `json:"right"`
+	Symbols   int       `json:"symbols"`
+	Contracts int       `json:"contracts"`
+}
+
+// NewScanRun records the contracts of a scan that started at start
+func NewScanRun(kind string, start time.Time, params ScanParams, symbols []string, contracts []OptionContract) ScanRun {
+	recorded := ScanRunParams{
+		Symbols:          symbols,
+		Right:            params.Right,
+		MinReturn:        params.MinReturn,
+		StrikeMode:       params.StrikeMode,
+		StrikeRange:      params.StrikeRange,
+		MinDelta:         params.MinDelta,
+		MaxDelta:         params.MaxDelta,
+		NumExpiries:      params.NumExpiries,
+		MaxDTE:           params.MaxDTE,
+		MinOpenInterest:  params.MinOpenInterest,
+		MinVolume:        params.MinVolume,
+		MaxSpreadPercent: params.MaxSpreadPercent,
+		FillMode:         params.FillMode,
+		EarningsMode:     params.EarningsMode,
+		Sized:            params.Sizing != nil,
+	}
+	if params.Scoring != nil {
+		recorded.ScoreWeights = params.Scoring.Weights.String()
+	}
+
+	return ScanRun{
+		ID:        start.Format(scanRunIDFormat) + "-" + kind,
+		Time:      start,
+		Kind:      kind,
+		Params:    recorded,
+		Contracts: contracts,
+	}
+}
+
+// saveHistory saves a batch run to params.HistoryDir and reports where; failures only warn,
+// since the scan itself succeeded
+func (p BatchScanParams) saveHistory(kind string, start time.Time, symbols []string, contracts []OptionContract) {
+	if p.HistoryDir == "" {
+		return
+	}
+	run := NewScanRun(kind, start, p.scanParams(""), symbols, contracts)
+	path, err := SaveScanRun(p.HistoryDir, run)
+	if err != nil {
+		fmt.Printf("   ⚠️  History not saved: %v\n", err)
+		return
+	}
+	fmt.Printf("   History: %s\n", path)
+}
+
+// SaveScanRun writes a run to dir/<id>.json and returns the path
+func SaveScanRun(dir string, run ScanRun) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return "", fmt.Errorf("encoding scan run: %w", err)
+	}
+
+	// Runs started in the same second get a suffix
+	path := filepath.Join(dir, run.ID+".json")
+	for i := 2; fileExists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d.json", run.ID, i))
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// ListScanRuns returns the saved runs of a kind ("" = all) in dir, oldest first
+func ListScanRuns(dir, kind string) ([]ScanRunInfo, error) {
+	ids, err := scanRunIDs(dir, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []ScanRunInfo
+	for _, id := range ids {
+		run, err := readScanRun(dir, id)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run.info())
+	}
+	return runs, nil
+}
+
+// LoadScanRun loads a saved run of a kind ("" = any) by ID, or "latest" / "previous" for the
+// last two runs. An ID prefix (e.g. "20261018") selects the latest run matching it.
+func LoadScanRun(dir, kind, ref string) (*ScanRun, error) {
+	ids, err := scanRunIDs(dir, kind)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no %sscan runs in %s", kindLabel(kind), dir)
+	}
+
+	var id string
+	switch ref {
+	case "", "latest":
+		id = ids[len(ids)-1]
+	case "previous":
+		if len(ids) < 2 {
+			return nil, fmt.Errorf("only one %sscan run in %s", kindLabel(kind), dir)
+		}
+		id = ids[len(ids)-2]
+	default:
+		for i := len(ids) - 1; i >= 0; i-- {
+			if strings.HasPrefix(ids[i], ref) {
+				id = ids[i]
+				break
+			}
+		}
+		if id == "" {
+			return nil, fmt.Errorf("no %sscan run %q in %s", kindLabel(kind), ref, dir)
+		}
+	}
+
+	return readScanRun(dir, id)
+}
+
+// scanRunIDs returns the IDs of the runs of a kind ("" = all) saved in dir, oldest first.
+// A kind matches itself and its refinements: "symbol" matches "symbol-AAPL".
+func scanRunIDs(dir, kind string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := len(scanRunIDFormat) + 1
+	var ids []string
+	for _, match := range matches {
+		id := strings.TrimSuffix(filepath.Base(match), ".json")
+		if kind != "" && (len(id) <= prefix || (id[prefix:] != kind && !strings.HasPrefix(id[prefix:], kind+"-"))) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// kindLabel returns "<kind> " for error messages, or "" for any kind
+func kindLabel(kind string) string {
+	if kind == "" {
+		return ""
+	}
+	return kind + " "
+}
+
+func readScanRun(dir, id string) (*ScanRun, error) {
+	path := filepath.Join(dir, id+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var run ScanRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	run.ID = id
+	return &run, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ContractChange is the premium move of a contract found in both runs
+type ContractChange struct {
+	ConID        int     `json:"conid"`
+	Symbol       string  `json:"symbol"`
+	Strike       float64 `json:"strike"`
+	Right        string  `json:"right"`
+	MaturityDate string  `json:"maturityDate"`
+
+	OldPrice      float64 `json:"oldPrice"` // Underlying price
+	NewPrice      float64 `json:"newPrice"`
+	OldPremium    float64 `json:"oldPremium"` // Premium at the fill price (total)
+	NewPremium    float64 `json:"newPremium"`
+	Change        float64 `json:"change"`        // NewPremium - OldPremium
+	ChangePercent float64 `json:"changePercent"` // Change as % of OldPremium
+	OldReturn     float64 `json:"oldReturn"`     // Net annualized return %
+	NewReturn     float64 `json:"newReturn"`
+}
+
+// ScanDiff compares two scan runs contract by contract (by ConID)
+type ScanDiff struct {
+	From ScanRunInfo `json:"from"`
+	To   ScanRunInfo `json:"to"`
+
+	Entered []OptionContract `json:"entered"` // Qualified in To but not in From
+	Dropped []OptionContract `json:"dropped"` // Qualified in From, still trading at To, but no longer qualify
+	Expired []OptionContract `json:"expired"` // Qualified in From and expired before To
+	Changed []ContractChange `json:"changed"` // In both runs, biggest premium moves first
+}
+
+// DiffScanRuns compares the contracts of two runs. Runs of different kinds, rights, filter
+// settings or, for single-symbol runs, tickers can't be compared.
+func DiffScanRuns(from, to *ScanRun) (ScanDiff, error) {
+	if err := comparableRuns(from, to); err != nil {
+		return ScanDiff{}, err
+	}
+
+	diff := ScanDiff{
+		From: from.info(),
+		To:   to.info(),
+	}
+
+	before := make(map[int]OptionContract)
+	for _, c := range from.Contracts {
+		before[c.ConID] = c
+	}
+	after := make(map[int]bool)
+
+	for _, c := range to.Contracts {
+		after[c.ConID] = true
+		old, ok := before[c.ConID]
+		if !ok {
+			diff.Entered = append(diff.Entered, c)
+			continue
+		}
+
+		change := ContractChange{
+			ConID:        c.ConID,
+			Symbol:       c.Symbol,
+			Strike:       c.Strike,
+			Right:        c.Right,
+			MaturityDate: c.MaturityDate,
+			OldPrice:     old.UnderlyingPrice,
+			NewPrice:     c.UnderlyingPrice,
+			OldPremium:   old.Premium,
+			NewPremium:   c.Premium,
+			Change:       c.Premium - old.Premium,
+			OldReturn:    old.NetAnnualizedReturn,
+			NewReturn:    c.NetAnnualizedReturn,
+		}
+		if old.Premium > 0 {
+			change.ChangePercent = change.Change / old.Premium * 100
+		}
+		diff.Changed = append(diff.Changed, change)
+	}
+
+	// Contracts are gone from To either because they expired or because they stopped qualifying
+	toDate := to.Time.Format("20060102")
+	for _, c := range from.Contracts {
+		if after[c.ConID] {
+			continue
+		}
+		if c.MaturityDate < toDate {
+			diff.Expired = append(diff.Expired, c)
+		} else {
+			diff.Dropped = append(diff.Dropped, c)
+		}
+	}
+
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return math.Abs(diff.Changed[i].Change) > math.Abs(diff.Changed[j].Change)
+	})
+	for _, contracts := range [][]OptionContract{diff.Entered, diff.Dropped, diff.Expired} {
+		sortByContract(contracts)
+	}
+
+	return diff, nil
+}
+
+// comparableRuns returns why two runs can't be diffed, or nil if they can
+func comparableRuns(from, to *ScanRun) error {
+	switch {
+	case from.Kind != to.Kind:
+		return fmt.Errorf("runs %s and %s are different kinds (%s, %s)", from.ID, to.ID, from.Kind, to.Kind)
+	case from.Params.Right != to.Params.Right:
+		return fmt.Errorf("runs %s and %s scan different rights (%s, %s)", from.ID, to.ID, from.Params.Right, to.Params.Right)
+	case from.Kind == ScanKindSymbol && strings.Join(from.Params.Symbols, ",") != strings.Join(to.Params.Symbols, ","):
+		// Single-symbol runs saved before the kind carried the ticker
+		return fmt.Errorf("runs %s and %s scan different symbols (%s, %s)", from.ID, to.ID,
+			strings.Join(from.Params.Symbols, ","), strings.Join(to.Params.Symbols, ","))
+	}
+	if changed := changedSettings(from.Params, to.Params); len(changed) > 0 {
+		// Contracts would enter and drop out because a filter changed, not the market
+		return fmt.Errorf("runs %s and %s used different settings (%s)", from.ID, to.ID, strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// changedSettings lists the settings deciding which contracts qualify that differ between two
+// runs, e.g. "minReturn 100 → 50"
+func changedSettings(from, to ScanRunParams) []string {
+	settings := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"minReturn", from.MinReturn, to.MinReturn},
+		{"strikeMode", from.StrikeMode, to.StrikeMode},
+		{"strikeRange", from.StrikeRange, to.StrikeRange},
+		{"minDelta", from.MinDelta, to.MinDelta},
+		{"maxDelta", from.MaxDelta, to.MaxDelta},
+		{"numExpiries", from.NumExpiries, to.NumExpiries},
+		{"maxDte", from.MaxDTE, to.MaxDTE},
+		{"minOpenInterest", from.MinOpenInterest, to.MinOpenInterest},
+		{"minVolume", from.MinVolume, to.MinVolume},
+		{"maxSpreadPercent", from.MaxSpreadPercent, to.MaxSpreadPercent},
+		{"fill", from.FillMode, to.FillMode},
+		{"earnings", from.EarningsMode, to.EarningsMode},
+	}
+
+	var changed []string
+	for _, setting := range settings {
+		if setting.from != setting.to {
+			changed = append(changed, fmt.Sprintf("%s %v → %v", setting.name, setting.from, setting.to))
+		}
+	}
+	return changed
+}
+
+// info summarizes a run
+func (r *ScanRun) info() ScanRunInfo {
+	symbols := make(map[string]bool)
+	for _, s := range r.Params.Symbols {
+		symbols[s] = true
+	}
+	return ScanRunInfo{
+		ID:        r.ID,
+		Time:      r.Time,
+		Kind:      r.Kind,
+		Right:     r.Params.Right,
+		Symbols:   len(symbols),
+		Contracts: len(r.Contracts),
+	}
+}
+
+// sortByContract orders contracts by symbol, expiry and strike
+func sortByContract(contracts []OptionContract) {
+	sort.Slice(contracts, func(i, j int) bool {
+		a, b := contracts[i], contracts[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.MaturityDate != b.MaturityDate {
+			return a.MaturityDate < b.MaturityDate
+		}
+		return a.Strike < b.Strike
+	})
+}
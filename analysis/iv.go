@@ -0,0 +1,237 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// DefaultIVHistoryCSV is where each symbol's daily ATM IV is recorded
+const DefaultIVHistoryCSV = "data/iv_history.csv"
+
+// minATMDTE skips expiries closer than this when reading ATM IV; IV in the last days of an
+// expiry swings too much to compare day to day
+const minATMDTE = 7
+
+// ivQuote is one quoted contract's implied volatility, for picking the at-the-money one and
+// building the volatility smile
+type ivQuote struct {
+	strike   float64
+	dte      int
+	iv       float64 // Annualized, as a fraction
+	right    string
+	delta    float64
+	mid      float64
+	maturity string
+}
+
+// atmIV returns the IV of the strike nearest the price in the first expiry at least minATMDTE
+// out (the last expiry when none is), or 0 without quotes
+func atmIV(quotes []ivQuote, price float64) float64 {
+	far, near := -1, -1
+	for _, q := range quotes {
+		switch {
+		case q.iv <= 0:
+		case q.dte >= minATMDTE:
+			if far < 0 || q.dte < far {
+				far = q.dte
+			}
+		case q.dte > near:
+			near = q.dte
+		}
+	}
+	dte := far
+	if dte < 0 {
+		dte = near
+	}
+	return expiryATMIV(quotes, price, dte)
+}
+
+// expiryATMIV returns the IV of the quoted strike nearest the price for one DTE (averaged when
+// both rights are quoted there), or 0 without quotes
+func expiryATMIV(quotes []ivQuote, price float64, dte int) float64 {
+	strike, distance := 0.0, math.Inf(1)
+	for _, q := range quotes {
+		if q.iv > 0 && q.dte == dte && math.Abs(q.strike-price) < distance {
+			strike, distance = q.strike, math.Abs(q.strike-price)
+		}
+	}
+
+	sum, n := 0.0, 0
+	for _, q := range quotes {
+		if q.iv > 0 && q.dte == dte && q.strike == strike {
+			sum += q.iv
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// rankIV records the ATM IV a symbol was scanned at on asOf's date in a copy of the history and
+// returns the symbol's IV stats
+func rankIV(history []web.IVObservation, symbol string, iv float64, asOf time.Time) (web.IVStats, bool) {
+	if iv <= 0 {
+		return web.CalculateIVStats(history, symbol)
+	}
+	today := web.IVObservation{Symbol: symbol, Date: asOf.Format("2006-01-02"), IV: iv}
+	return web.CalculateIVStats(web.RecordIV(append([]web.IVObservation(nil), history...), today), symbol)
+}
+
+// applyIVStats sets a contract's ATM IV, and its IV rank and percentile over the longest window
+// with enough history
+func applyIVStats(contract *OptionContract, stats web.IVStats) {
+	contract.ATMIV = stats.Current
+	if best, ok := stats.Best(); ok {
+		contract.IVRank = best.Rank
+		contract.IVPercentile = best.Percentile
+		contract.IVRankWindow = best.Window
+	}
+}
+
+// recordIV adds the ATM IVs of scanned symbols to params.IVHistoryCSV; failures only warn,
+// since the scan itself succeeded
+func (p BatchScanParams) recordIV(results []*SymbolScan) {
+	if p.IVHistoryCSV == "" {
+		return
+	}
+	recorded, err := RecordATMIVs(p.IVHistoryCSV, results)
+	if err != nil {
+		fmt.Printf("   ⚠️  IV history not saved: %v\n", err)
+		return
+	}
+	if recorded > 0 {
+		fmt.Printf("   IV history: %d symbols → %s\n", recorded, p.IVHistoryCSV)
+	}
+}
+
+// RecordATMIVs adds today's ATM IV of each scanned symbol to the IV history file and returns
+// how many were recorded
+func RecordATMIVs(filename string, results []*SymbolScan) (int, error) {
+	ivs := make(map[string]float64)
+	for _, result := range results {
+		if result != nil && result.ATMIV > 0 {
+			ivs[result.Symbol] = result.ATMIV
+		}
+	}
+	if len(ivs) == 0 {
+		return 0, nil
+	}
+	return len(ivs), recordIVs(filename, ivs, time.Now())
+}
+
+// recordIVs adds each symbol's IV on date's day to the IV history file
+func recordIVs(filename string, ivs map[string]float64, date time.Time) error {
+	history := web.LoadIVHistory(filename)
+	for symbol, iv := range ivs {
+		history = web.RecordIV(history, web.IVObservation{Symbol: symbol, Date: date.Format("2006-01-02"), IV: iv})
+	}
+	return web.SaveIVHistory(filename, history)
+}
+
+// CollectATMIV reads a symbol's price and at-the-money put IV from the provider, from the
+// nearest strike of the first expiry at least minATMDTE days out
+func CollectATMIV(provider MarketDataProvider, symbol, exchange string) (price, iv float64, err error) {
+	conID, err := provider.LookupUnderlying(symbol, exchange)
+	if err != nil {
+		return 0, 0, fmt.Errorf("searching underlying: %w", err)
+	}
+	if price, err = provider.LastPrice(conID); err != nil {
+		return 0, 0, fmt.Errorf("getting current price: %w", err)
+	}
+	months, err := provider.Expirations(conID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("getting expirations: %w", err)
+	}
+
+	now := marketTime(provider)
+	var quotes []ivQuote
+	for _, month := range selectExpiryMonths(months, 2, 0, now) {
+		strikes, err := provider.Strikes(conID, month, "P")
+		if err != nil || len(strikes) == 0 {
+			continue
+		}
+		strike := strikes[0]
+		for _, s := range strikes {
+			if math.Abs(s-price) < math.Abs(strike-price) {
+				strike = s
+			}
+		}
+
+		contracts, err := provider.Contracts(conID, month, strike, "P")
+		if err != nil {
+			continue
+		}
+		farEnough := false
+		for _, contract := range contracts {
+			pricing, err := provider.OptionQuote(contract.ConID)
+			if err != nil || pricing.ImpliedVol <= 0 {
+				continue
+			}
+			dte := daysToExpiry(contract.MaturityDate, now)
+			quotes = append(quotes, ivQuote{strike: strike, dte: dte, iv: NormalizeIV(pricing.ImpliedVol)})
+			farEnough = farEnough || dte >= minATMDTE
+		}
+
+		// The next month is only needed when this one expires too soon
+		if farEnough {
+			break
+		}
+	}
+
+	if iv = atmIV(quotes, price); iv <= 0 {
+		return price, 0, fmt.Errorf("no at-the-money IV quoted")
+	}
+	return price, iv, nil
+}
+
+// IVCollection is the outcome of recording the ATM IV of a list of symbols
+type IVCollection struct {
+	IVs    map[string]float64 // symbol -> ATM IV recorded today
+	Failed map[string]string  // symbol -> error
+}
+
+// CollectIVHistory reads the ATM IV of every symbol (all of data/universe.csv when none are given)
+// from the provider and records them to the IV history file, dated the day a replayed chain was
+// captured
+func CollectIVHistory(provider MarketDataProvider, filename string, symbols []string, exchange string, workers int) (*IVCollection, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	if len(symbols) == 0 {
+		stocks, err := loadUniverse()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load universe: %w", err)
+		}
+		for _, stock := range stocks {
+			symbols = append(symbols, stock.Symbol)
+		}
+	}
+
+	collection := &IVCollection{IVs: make(map[string]float64), Failed: make(map[string]string)}
+	var mu sync.Mutex
+
+	forEach(len(symbols), workers, func(i int) {
+		_, iv, err := CollectATMIV(provider, symbols[i], exchange)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			collection.Failed[symbols[i]] = err.Error()
+			return
+		}
+		collection.IVs[symbols[i]] = iv
+	})
+
+	if err := recordIVs(filename, collection.IVs, marketTime(provider)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", filename, err)
+	}
+
+	return collection, nil
+}
@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fill assumptions for ScanParams.FillMode
+const (
+	FillMid      = "mid"       // Fill at the mid price (default)
+	FillBid      = "bid"       // Fill at the bid, the worst case for a seller
+	FillMidMinus = "mid-minus" // Fill at mid minus FillFraction of the spread
+)
+
+// DefaultFillFraction is the share of the spread given up in mid-minus mode when none is set
+const DefaultFillFraction = 0.25
+
+// Quote summarizes the usable prices of an option quote
+type Quote struct {
+	Mid           float64 // Mid price, or the one available side of a one-sided quote
+	Spread        float64 // Ask - bid (0 for one-sided quotes)
+	SpreadPercent float64 // Spread as % of mid (100 for one-sided quotes)
+}
+
+// NewQuote computes mid and spread from a bid/ask pair
+func NewQuote(bid, ask float64) Quote {
+	switch {
+	case bid > 0 && ask > 0:
+		mid := (bid + ask) / 2
+		spread := math.Max(0, ask-bid)
+		return Quote{Mid: mid, Spread: spread, SpreadPercent: spread / mid * 100}
+	case ask > 0:
+		return Quote{Mid: ask, SpreadPercent: 100}
+	default:
+		return Quote{Mid: bid, SpreadPercent: 100}
+	}
+}
+
+// FillPrice returns the per-share price a sell order is assumed to fill at
+func FillPrice(bid, ask float64, mode string, fraction float64) float64 {
+	quote := NewQuote(bid, ask)
+
+	switch mode {
+	case FillBid:
+		return bid
+	case FillMidMinus:
+		if fraction <= 0 {
+			fraction = DefaultFillFraction
+		}
+		return math.Max(bid, quote.Mid-fraction*quote.Spread)
+	default: // FillMid
+		return quote.Mid
+	}
+}
+
+// BuyFillPrice returns the per-share price a buy order (e.g., buying to close) is assumed to fill at.
+// It mirrors FillPrice: bid mode assumes the ask, mid-minus pays mid plus the fraction of spread.
+func BuyFillPrice(bid, ask float64, mode string, fraction float64) float64 {
+	quote := NewQuote(bid, ask)
+	if ask <= 0 {
+		return quote.Mid
+	}
+
+	switch mode {
+	case FillBid:
+		return ask
+	case FillMidMinus:
+		if fraction <= 0 {
+			fraction = DefaultFillFraction
+		}
+		return math.Min(ask, quote.Mid+fraction*quote.Spread)
+	default: // FillMid
+		return quote.Mid
+	}
+}
+
+// liquidityRejection checks a contract against the scan's liquidity minimums and returns
+// why it fails them, or "" if it passes
+func liquidityRejection(params ScanParams, contract OptionContract) (reason, detail string) {
+	if params.MinOpenInterest > 0 && contract.OpenInterest < params.MinOpenInterest {
+		return "open interest too low", fmt.Sprintf("%d < %d", contract.OpenInterest, params.MinOpenInterest)
+	}
+	if params.MinVolume > 0 && contract.Volume < params.MinVolume {
+		return "volume too low", fmt.Sprintf("%d < %d", contract.Volume, params.MinVolume)
+	}
+	if params.MaxSpreadPercent > 0 && contract.SpreadPercent > params.MaxSpreadPercent {
+		return "spread too wide", fmt.Sprintf("%.0f%% > %.0f%%", contract.SpreadPercent, params.MaxSpreadPercent)
+	}
+	return "", ""
+}
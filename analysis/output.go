@@ -0,0 +1,509 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result output formats
+const (
+	FormatCSV    = "csv"    // One header row, then one row per result
+	FormatJSON   = "json"   // One array of objects
+	FormatNDJSON = "ndjson" // One object per line, streamed as results arrive
+	FormatSQLite = "sqlite" // Rows appended to a table of a SQLite database (needs the sqlite3 command)
+)
+
+// ResultFormats lists the output formats
+var ResultFormats = []string{FormatCSV, FormatJSON, FormatNDJSON, FormatSQLite}
+
+// Column value types
+const (
+	ColumnText = "text"
+	ColumnInt  = "int"
+	ColumnReal = "real"
+	ColumnBool = "bool"
+)
+
+// Column is one field of a result schema
+type Column struct {
+	Name     string
+	Type     string // ColumnText, ColumnInt, ColumnReal or ColumnBool
+	Decimals int    // Digits after the point for ColumnReal
+}
+
+// ResultSchema names the columns of a result and the SQLite table it's written to
+type ResultSchema struct {
+	Table   string
+	Columns []Column
+}
+
+// Record holds one value per schema column, in column order: string, int, float64 or bool
+type Record []any
+
+// ResultWriter writes scan results in one of the ResultFormats. Call Begin once with the schema,
+// Write every record, then Close.
+type ResultWriter interface {
+	Begin(schema ResultSchema) error
+	Write(record Record) error
+	Close() error
+}
+
+// ResultFormatFor infers the format of an output path from its extension: .json, .ndjson or
+// .jsonl, .db, .sqlite or .sqlite3, and CSV otherwise. Stdout ("-") defaults to NDJSON.
+func ResultFormatFor(path string) string {
+	if path == "-" {
+		return FormatNDJSON
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	case ".db", ".sqlite", ".sqlite3":
+		return FormatSQLite
+	default:
+		return FormatCSV
+	}
+}
+
+// resultStdout is the process's standard output, captured at startup, so commands streaming
+// results to "-" can send their progress to stderr by reassigning os.Stdout
+var resultStdout io.Writer = os.Stdout
+
+// OpenResultWriter opens a writer for path in format ("" = inferred from the path).
+// Path "-" writes to standard output.
+func OpenResultWriter(format, path string) (ResultWriter, error) {
+	if format == "" {
+		format = ResultFormatFor(path)
+	}
+
+	switch format {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+	case FormatSQLite:
+		if path == "-" {
+			return nil, fmt.Errorf("sqlite output needs a database file, not stdout")
+		}
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			return nil, fmt.Errorf("sqlite output needs the sqlite3 command: %w", err)
+		}
+		return &sqliteResultWriter{path: path, start: time.Now()}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (want %s)", format, strings.Join(ResultFormats, ", "))
+	}
+
+	out := resultStdout
+	var file *os.File
+	if path != "-" {
+		var err error
+		if file, err = os.Create(path); err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	if format == FormatCSV {
+		return &csvResultWriter{file: file, writer: csv.NewWriter(out)}, nil
+	}
+	return &jsonResultWriter{file: file, out: out, lines: format == FormatNDJSON}, nil
+}
+
+// checkRecord verifies a record matches the schema it's written with
+func checkRecord(columns []Column, record Record) error {
+	if len(record) != len(columns) {
+		return fmt.Errorf("record has %d values for %d columns", len(record), len(columns))
+	}
+	return nil
+}
+
+// formatValue formats a value as CSV text
+func formatValue(column Column, value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', column.Decimals, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvResultWriter writes CSV, flushing after every row
+type csvResultWriter struct {
+	file    *os.File // nil for stdout
+	writer  *csv.Writer
+	columns []Column
+}
+
+func (w *csvResultWriter) Begin(schema ResultSchema) error {
+	w.columns = schema.Columns
+	header := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		header[i] = column.Name
+	}
+	return w.flush(w.writer.Write(header))
+}
+
+func (w *csvResultWriter) Write(record Record) error {
+	if err := checkRecord(w.columns, record); err != nil {
+		return err
+	}
+	row := make([]string, len(record))
+	for i, value := range record {
+		row[i] = formatValue(w.columns[i], value)
+	}
+	return w.flush(w.writer.Write(row))
+}
+
+func (w *csvResultWriter) flush(err error) error {
+	if err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvResultWriter) Close() error {
+	w.writer.Flush()
+	err := w.writer.Error()
+	if w.file != nil {
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// jsonResultWriter writes records as JSON objects with keys in column order, either as one
+// array or one object per line
+type jsonResultWriter struct {
+	file    *os.File // nil for stdout
+	out     io.Writer
+	lines   bool // NDJSON
+	columns []Column
+	count   int
+}
+
+func (w *jsonResultWriter) Begin(schema ResultSchema) error {
+	w.columns = schema.Columns
+	if w.lines {
+		return nil
+	}
+	_, err := io.WriteString(w.out, "[")
+	return err
+}
+
+func (w *jsonResultWriter) Write(record Record) error {
+	if err := checkRecord(w.columns, record); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch {
+	case w.lines:
+	case w.count == 0:
+		buf.WriteString("\n  ")
+	default:
+		buf.WriteString(",\n  ")
+	}
+
+	buf.WriteByte('{')
+	for i, value := range record {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(w.columns[i].Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.WriteString(jsonValue(w.columns[i], value))
+	}
+	buf.WriteByte('}')
+	if w.lines {
+		buf.WriteByte('\n')
+	}
+
+	w.count++
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+func (w *jsonResultWriter) Close() error {
+	var err error
+	if !w.lines {
+		end := "\n]\n"
+		if w.count == 0 {
+			end = "]\n"
+		}
+		_, err = io.WriteString(w.out, end)
+	}
+	if w.file != nil {
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// jsonValue encodes a value, rounding reals like the CSV does; NaN and infinities become null
+func jsonValue(column Column, value any) string {
+	if v, ok := value.(float64); ok {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "null"
+		}
+		return strconv.FormatFloat(v, 'f', column.Decimals, 64)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
+
+// sqliteResultWriter collects INSERT statements and runs them through the sqlite3 command in one
+// transaction on Close. Rows carry a ScannedAt column, so a database accumulates scans over time.
+type sqliteResultWriter struct {
+	path    string
+	start   time.Time
+	table   string
+	columns []Column
+	sql     strings.Builder
+}
+
+func (w *sqliteResultWriter) Begin(schema ResultSchema) error {
+	w.table = schema.Table
+	w.columns = schema.Columns
+
+	definitions := []string{`"ScannedAt" TEXT`}
+	for _, column := range w.columns {
+		definitions = append(definitions, fmt.Sprintf("%s %s", sqlIdentifier(column.Name), sqlType(column.Type)))
+	}
+	fmt.Fprintf(&w.sql, "CREATE TABLE IF NOT EXISTS %s (%s);\n", sqlIdentifier(w.table), strings.Join(definitions, ", "))
+	return nil
+}
+
+func (w *sqliteResultWriter) Write(record Record) error {
+	if err := checkRecord(w.columns, record); err != nil {
+		return err
+	}
+
+	names := []string{`"ScannedAt"`}
+	values := []string{sqlString(w.start.Format(time.RFC3339))}
+	for i, value := range record {
+		names = append(names, sqlIdentifier(w.columns[i].Name))
+		values = append(values, sqlValue(value))
+	}
+	fmt.Fprintf(&w.sql, "INSERT INTO %s (%s) VALUES (%s);\n", sqlIdentifier(w.table), strings.Join(names, ", "), strings.Join(values, ", "))
+	return nil
+}
+
+func (w *sqliteResultWriter) Close() error {
+	cmd := exec.Command("sqlite3", "-bail", w.path)
+	cmd.Stdin = strings.NewReader("BEGIN;\n" + w.sql.String() + "COMMIT;\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sqlite3 %s: %w: %s", w.path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func sqlType(columnType string) string {
+	switch columnType {
+	case ColumnInt, ColumnBool:
+		return "INTEGER"
+	case ColumnReal:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+func sqlValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return sqlString(fmt.Sprint(v))
+	}
+}
+
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sqlIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ContractSchema is the schema every contract scan writes (scan-all, ibkr-quote and the pipeline)
+var ContractSchema = ResultSchema{
+	Table: "contracts",
+	Columns: []Column{
+		{"Symbol", ColumnText, 0},
+		{"Strike", ColumnReal, 2},
+		{"Right", ColumnText, 0},
+		{"MaturityDate", ColumnText, 0},
+		{"DTE", ColumnInt, 0},
+		{"Premium", ColumnReal, 2},
+		{"IntrinsicValue", ColumnReal, 2},
+		{"ExtrinsicValue", ColumnReal, 2},
+		{"PremiumPercent", ColumnReal, 2},
+		{"AnnualizedReturn", ColumnReal, 2},
+		{"POP", ColumnReal, 2},
+		{"ProbAssignment", ColumnReal, 2},
+		{"ProbTouch", ColumnReal, 2},
+		{"Breakeven", ColumnReal, 2},
+		{"ExpectedMove", ColumnReal, 2},
+		{"ExpectedMoveStraddle", ColumnReal, 2},
+		{"BreakevenMoves", ColumnReal, 2},
+		{"Efficiency", ColumnReal, 2},
+		{"Commission", ColumnReal, 2},
+		{"NetPremium", ColumnReal, 2},
+		{"NetAnnualizedReturn", ColumnReal, 2},
+		{"NetEfficiency", ColumnReal, 2},
+		{"ITM", ColumnBool, 0},
+		{"Delta", ColumnReal, 4},
+		{"Gamma", ColumnReal, 4},
+		{"Theta", ColumnReal, 4},
+		{"Vega", ColumnReal, 4},
+		{"ImpliedVol", ColumnReal, 4},
+		{"ATMIV", ColumnReal, 4},
+		{"IVRank", ColumnReal, 1},
+		{"IVPercentile", ColumnReal, 1},
+		{"IVRankWindow", ColumnInt, 0},
+		{"HV", ColumnReal, 4},
+		{"IVHVRatio", ColumnReal, 2},
+		{"Bid", ColumnReal, 2},
+		{"Ask", ColumnReal, 2},
+		{"MidPrice", ColumnReal, 2},
+		{"FillPrice", ColumnReal, 2},
+		{"Spread", ColumnReal, 2},
+		{"SpreadPercent", ColumnReal, 1},
+		{"OpenInterest", ColumnInt, 0},
+		{"Volume", ColumnInt, 0},
+		{"UnderlyingPrice", ColumnReal, 2},
+		{"CapitalRequired", ColumnReal, 2},
+		{"ConID", ColumnInt, 0},
+		{"UnderlyingConID", ColumnInt, 0},
+		{"EarningsDate", ColumnText, 0},
+		{"RiskPerContract", ColumnReal, 2},
+		{"MaxContractsByRisk", ColumnInt, 0},
+		{"MaxContractsByPosition", ColumnInt, 0},
+		{"MaxContractsByCash", ColumnInt, 0},
+		{"RecommendedContracts", ColumnInt, 0},
+		{"Score", ColumnReal, 1},
+		{"ScoreReturn", ColumnReal, 1},
+		{"ScorePOP", ColumnReal, 1},
+		{"ScoreLiquidity", ColumnReal, 1},
+		{"ScoreIVRank", ColumnReal, 1},
+		{"ScoreIVHV", ColumnReal, 1},
+		{"ScoreOTM", ColumnReal, 1},
+		{"ScoreEarnings", ColumnReal, 1},
+		{"ScoreDiversification", ColumnReal, 1},
+	},
+}
+
+// ContractRecord returns the ContractSchema values of a contract
+func ContractRecord(c OptionContract) Record {
+	return Record{
+		c.Symbol,
+		c.Strike,
+		c.Right,
+		c.MaturityDate,
+		c.DTE,
+		c.Premium,
+		c.IntrinsicValue,
+		c.ExtrinsicValue,
+		c.PremiumPercent,
+		c.AnnualizedReturn,
+		c.POP,
+		c.ProbAssignment,
+		c.ProbTouch,
+		c.Breakeven,
+		c.ExpectedMove,
+		c.ExpectedMoveStraddle,
+		c.BreakevenMoves,
+		c.Efficiency,
+		c.Commission,
+		c.NetPremium,
+		c.NetAnnualizedReturn,
+		c.NetEfficiency,
+		c.IsITM,
+		c.Delta,
+		c.Gamma,
+		c.Theta,
+		c.Vega,
+		c.ImpliedVol,
+		c.ATMIV,
+		c.IVRank,
+		c.IVPercentile,
+		c.IVRankWindow,
+		c.HV,
+		c.IVHVRatio,
+		c.Bid,
+		c.Ask,
+		c.MidPrice,
+		c.FillPrice,
+		c.Spread,
+		c.SpreadPercent,
+		c.OpenInterest,
+		c.Volume,
+		c.UnderlyingPrice,
+		c.CapitalRequired,
+		c.ConID,
+		c.UnderlyingConID,
+		c.EarningsDate,
+		c.RiskPerContract,
+		c.MaxContractsByRisk,
+		c.MaxContractsByPosition,
+		c.MaxContractsByCash,
+		c.RecommendedContracts,
+		c.Score,
+		c.ScoreBreakdown.Return,
+		c.ScoreBreakdown.POP,
+		c.ScoreBreakdown.Liquidity,
+		c.ScoreBreakdown.IVRank,
+		c.ScoreBreakdown.IVHV,
+		c.ScoreBreakdown.OTM,
+		c.ScoreBreakdown.Earnings,
+		c.ScoreBreakdown.Diversification,
+	}
+}
+
+// WriteContracts writes contracts with ContractSchema to path in format ("" = inferred)
+func WriteContracts(format, path string, contracts []OptionContract) error {
+	writer, err := OpenResultWriter(format, path)
+	if err != nil {
+		return err
+	}
+	if err := writer.Begin(ContractSchema); err != nil {
+		writer.Close()
+		return err
+	}
+	for _, contract := range contracts {
+		if err := writer.Write(ContractRecord(contract)); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
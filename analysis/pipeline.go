@@ -0,0 +1,439 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"mnmlsm/ibkr"
+	"mnmlsm/web"
+)
+
+// DefaultWorkers is the number of concurrent workers used when a scan doesn't specify one
+const DefaultWorkers = 8
+
+// SymbolScan is the result of running the scan pipeline for one symbol
+type SymbolScan struct {
+	Symbol          string
+	UnderlyingConID int
+	Price           float64
+	Expiries        []string       // Option months that were scanned
+	ExpiryCounts    map[string]int // Qualifying contracts per option month
+	Contracts       []OptionContract
+	ATMIV           float64         // IV of the quoted strike nearest the price (0 = none quoted)
+	IV              web.IVStats     // ATMIV ranked against ScanParams.IVHistory
+	HV              []HistoricalVol // Realized volatility over HVWindows (nil = no price history)
+	ExpectedMoves   []ExpiryMove    // Market-implied move by each scanned maturity, nearest first
+	Surface         web.VolSurface  // Smile of each scanned maturity and the term structure across them
+
+	// Explain mode: where strikes and contracts dropped out
+	Funnels    []ExpiryFunnel // One per scanned month, in Expiries order
+	Rejections []Rejection
+}
+
+// strikeJob is one (month, strike) pair to resolve into contracts
+type strikeJob struct {
+	month  string
+	strike float64
+}
+
+// contractJob is one resolved contract waiting for pricing
+type contractJob struct {
+	month    string
+	strike   float64
+	contract ibkr.ContractInfo
+	dte      int
+}
+
+// scanSymbol runs the scan pipeline for one symbol:
+// resolve → expiries → strikes → contracts → pricing → metrics → filters → IV rank and score
+func (s *Scanner) scanSymbol(params ScanParams) (*SymbolScan, error) {
+	workers := params.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if params.AsOf.IsZero() {
+		params.AsOf = marketTime(s.provider)
+	}
+
+	// 1. Resolve underlying and its option months
+	conID, err := s.provider.LookupUnderlying(params.Symbol, params.Exchange)
+	if err != nil {
+		return nil, fmt.Errorf("searching underlying: %w", err)
+	}
+
+	currentPrice, err := s.provider.LastPrice(conID)
+	if err != nil {
+		return nil, fmt.Errorf("getting current price: %w", err)
+	}
+
+	months, err := s.provider.Expirations(conID)
+	if err != nil {
+		return nil, fmt.Errorf("getting expirations: %w", err)
+	}
+
+	result := &SymbolScan{
+		Symbol:          params.Symbol,
+		UnderlyingConID: conID,
+		Price:           currentPrice,
+		ExpiryCounts:    make(map[string]int),
+		HV:              fetchHistoricalVol(s.provider, conID),
+	}
+
+	// 2. Pick the expiry months to scan
+	result.Expiries = selectExpiryMonths(months, params.NumExpiries, params.MaxDTE, params.AsOf)
+	if len(result.Expiries) == 0 {
+		return nil, fmt.Errorf("no valid expiries found (%d months listed, max DTE %d)", len(months), params.MaxDTE)
+	}
+	result.Funnels = make([]ExpiryFunnel, len(result.Expiries))
+	funnelIndex := make(map[string]int)
+	for i, month := range result.Expiries {
+		result.Funnels[i].Month = month
+		funnelIndex[month] = i
+	}
+
+	// 3. Strikes for each month
+	strikesByMonth := make([][]float64, len(result.Expiries))
+	strikeRejections := make([][]Rejection, len(result.Expiries))
+	atmStrikes := make([]float64, len(result.Expiries))
+	forEach(len(result.Expiries), workers, func(i int) {
+		month := result.Expiries[i]
+		strikes, err := s.provider.Strikes(conID, month, params.Right)
+		if err != nil {
+			strikeRejections[i] = append(strikeRejections[i], Rejection{Month: month, Stage: StageStrike, Reason: "strike lookup failed", Detail: err.Error()})
+			return // Skip months with errors
+		}
+		strikesByMonth[i] = selectStrikes(strikes, currentPrice, params)
+		atmStrikes[i] = nearestStrike(strikes, currentPrice)
+
+		result.Funnels[i].Strikes = len(strikes)
+		result.Funnels[i].InRange = len(strikesByMonth[i])
+		selected := make(map[float64]bool)
+		for _, strike := range strikesByMonth[i] {
+			selected[strike] = true
+		}
+		for _, strike := range strikes {
+			if !selected[strike] {
+				reason, detail := strikeRejection(strike, strikes, currentPrice, params)
+				strikeRejections[i] = append(strikeRejections[i], Rejection{Month: month, Strike: strike, Stage: StageStrike, Reason: reason, Detail: detail})
+			}
+		}
+	})
+
+	var strikeJobs []strikeJob
+	for i, month := range result.Expiries {
+		for _, strike := range strikesByMonth[i] {
+			strikeJobs = append(strikeJobs, strikeJob{month: month, strike: strike})
+		}
+		result.Rejections = append(result.Rejections, strikeRejections[i]...)
+	}
+
+	// 4. Contract definitions for each strike
+	contractsByStrike := make([][]contractJob, len(strikeJobs))
+	contractRejections := make([][]Rejection, len(strikeJobs))
+	forEach(len(strikeJobs), workers, func(i int) {
+		job := strikeJobs[i]
+		reject := func(conID int, maturity, reason, detail string) {
+			contractRejections[i] = append(contractRejections[i], Rejection{
+				Month: job.month, Strike: job.strike, ConID: conID, MaturityDate: maturity, Stage: StageContract, Reason: reason, Detail: detail,
+			})
+		}
+
+		contracts, err := s.provider.Contracts(conID, job.month, job.strike, params.Right)
+		if err != nil {
+			reject(0, "", "contract lookup failed", err.Error())
+			return // Skip strikes with errors
+		}
+		if len(contracts) == 0 {
+			reject(0, "", "no contracts listed", "")
+		}
+		for _, contract := range contracts {
+			dte := daysToExpiry(contract.MaturityDate, params.AsOf)
+			if params.MaxDTE > 0 && dte > params.MaxDTE {
+				reject(contract.ConID, contract.MaturityDate, "beyond max DTE", fmt.Sprintf("%d > %d days", dte, params.MaxDTE))
+				continue
+			}
+			if dte < params.MinDTE {
+				reject(contract.ConID, contract.MaturityDate, "below min DTE", fmt.Sprintf("%d < %d days", dte, params.MinDTE))
+				continue
+			}
+			contractsByStrike[i] = append(contractsByStrike[i], contractJob{
+				month:    job.month,
+				strike:   job.strike,
+				contract: contract,
+				dte:      dte,
+			})
+		}
+	})
+
+	var contractJobs []contractJob
+	seen := make(map[int]bool)
+	for i, jobs := range contractsByStrike {
+		for _, job := range jobs {
+			if seen[job.contract.ConID] {
+				continue
+			}
+			seen[job.contract.ConID] = true
+			contractJobs = append(contractJobs, job)
+			result.Funnels[funnelIndex[job.month]].Contracts++
+		}
+		result.Rejections = append(result.Rejections, contractRejections[i]...)
+	}
+
+	// 5. Pricing, metrics and filters for each contract
+	priced := make([]*OptionContract, len(contractJobs))
+	quoted := make([]bool, len(contractJobs))
+	ivQuotes := make([]ivQuote, len(contractJobs))
+	pricingRejections := make([]*Rejection, len(contractJobs))
+	forEach(len(contractJobs), workers, func(i int) {
+		job := contractJobs[i]
+		reject := func(stage, reason, detail string) {
+			pricingRejections[i] = &Rejection{
+				Month: job.month, Strike: job.strike, ConID: job.contract.ConID, MaturityDate: job.contract.MaturityDate, Stage: stage, Reason: reason, Detail: detail,
+			}
+		}
+
+		pricing, err := s.provider.OptionQuote(job.contract.ConID)
+		if err != nil {
+			reject(StagePricing, "quote failed", err.Error())
+			return // Skip contracts with pricing errors
+		}
+
+		contract, ok := buildContract(params, conID, currentPrice, job, pricing)
+		if !ok {
+			reject(StagePricing, "no bid or ask", "")
+			return
+		}
+		quoted[i] = true
+		ivQuotes[i] = ivQuote{
+			strike: job.strike, dte: job.dte, iv: NormalizeIV(pricing.ImpliedVol),
+			right: params.Right, delta: contract.Delta, mid: contract.MidPrice, maturity: job.contract.MaturityDate,
+		}
+
+		if reason, detail := filterRejection(params, &contract); reason != "" {
+			reject(StageFilter, reason, detail)
+			return
+		}
+		sizeContract(params.Sizing, &contract)
+		priced[i] = &contract
+	})
+
+	// 6. IV rank of the symbol from today's ATM IV, its IV/HV ratios and the expected move by
+	// each expiry (from ATM IV and from the ATM straddle)
+	legs := fetchStraddleLegs(s.provider, conID, currentPrice, result.Expiries, atmStrikes, params.AsOf, workers)
+	var quotes []ivQuote
+	for i := range contractJobs {
+		if quoted[i] {
+			quotes = append(quotes, ivQuotes[i])
+		}
+	}
+	quotes = append(quotes, legs...)
+
+	result.ATMIV = atmIV(quotes, currentPrice)
+	maturities := make(map[int]string)
+	for _, q := range quotes {
+		maturities[q.dte] = q.maturity
+	}
+	result.ExpectedMoves = expiryMoves(quotes, maturities, straddleMids(legs), currentPrice)
+	result.Surface = volSurface(params.Symbol, currentPrice, params.AsOf, quotes)
+	ivStats, haveIV := rankIV(params.IVHistory, params.Symbol, result.ATMIV, params.AsOf)
+	result.IV = ivStats
+
+	for i, contract := range priced {
+		funnel := &result.Funnels[funnelIndex[contractJobs[i].month]]
+		if quoted[i] {
+			funnel.Priced++
+		}
+		if pricingRejections[i] != nil {
+			result.Rejections = append(result.Rejections, *pricingRejections[i])
+		}
+		if contract == nil {
+			continue
+		}
+		if haveIV {
+			applyIVStats(contract, ivStats)
+		}
+		applyHV(contract, result.HV)
+		applyExpectedMove(contract, result.ExpectedMoves)
+		scoreContract(params.Scoring, contract)
+
+		funnel.Passed++
+		result.Contracts = append(result.Contracts, *contract)
+		result.ExpiryCounts[contractJobs[i].month]++
+	}
+
+	return result, nil
+}
+
+// buildContract computes pricing-derived metrics for one contract.
+// Returns false if the contract has no usable quote.
+func buildContract(params ScanParams, underlyingConID int, currentPrice float64, job contractJob, pricing *ibkr.OptionPricing) (OptionContract, bool) {
+	// Skip if no valid bid or ask
+	if pricing.Bid <= 0 && pricing.Ask <= 0 {
+		return OptionContract{}, false
+	}
+
+	strike := job.strike
+	dte := job.dte
+
+	// Mid price and spread (mid uses the available side if one is missing)
+	quote := NewQuote(pricing.Bid, pricing.Ask)
+	midPrice := quote.Mid
+
+	// Premium is taken at the assumed fill, not always mid
+	fillPrice := FillPrice(pricing.Bid, pricing.Ask, params.FillMode, params.FillFraction)
+
+	// Calculate intrinsic and extrinsic value
+	var intrinsicValue float64
+	var isITM bool
+
+	if params.Right == "P" {
+		// Put: intrinsic = max(0, strike - stock price)
+		intrinsicValue = math.Max(0, strike-currentPrice)
+		isITM = strike > currentPrice
+	} else {
+		// Call: intrinsic = max(0, stock price - strike)
+		intrinsicValue = math.Max(0, currentPrice-strike)
+		isITM = currentPrice > strike
+	}
+
+	// Extrinsic value (time premium) = total premium - intrinsic
+	extrinsicValue := math.Max(0, fillPrice-intrinsicValue)
+
+	// Calculate metrics using EXTRINSIC VALUE (time premium only)
+	// Same-day expiries are annualized over one day
+	premiumPercent := (extrinsicValue / strike) * 100
+	annualizedReturn := CalculateAnnualizedReturn(extrinsicValue, strike, int(math.Max(1, float64(dte))))
+
+	// Net of the commission and fees to open; expiring worthless costs nothing to close
+	var commission float64
+	if params.Commissions != nil {
+		commission = params.Commissions.OptionCommission(1, fillPrice, true)
+	}
+	netPremium := extrinsicValue*100 - commission
+	netAnnualizedReturn := CalculateAnnualizedReturn(netPremium/100, strike, int(math.Max(1, float64(dte))))
+
+	// Fall back to the model delta when the quote has none
+	delta := pricing.Delta
+	if delta == 0 {
+		delta = ModelDelta(params.Right, currentPrice, strike, pricing.ImpliedVol, dte)
+	}
+
+	// Calculate model-based probabilities from IV and DTE
+	probs := CalculateProbabilities(params.Right, currentPrice, strike, fillPrice, pricing.ImpliedVol, delta, dte)
+
+	return OptionContract{
+		Symbol:              params.Symbol,
+		Strike:              strike,
+		Right:               params.Right,
+		MaturityDate:        job.contract.MaturityDate,
+		ConID:               job.contract.ConID,
+		UnderlyingConID:     underlyingConID,
+		Bid:                 pricing.Bid,
+		Ask:                 pricing.Ask,
+		MidPrice:            midPrice,
+		FillPrice:           fillPrice,
+		UnderlyingPrice:     currentPrice,
+		Spread:              quote.Spread,
+		SpreadPercent:       quote.SpreadPercent,
+		OpenInterest:        pricing.OpenInterest,
+		Volume:              pricing.Volume,
+		Delta:               delta,
+		Gamma:               pricing.Gamma,
+		Theta:               pricing.Theta,
+		Vega:                pricing.Vega,
+		ImpliedVol:          pricing.ImpliedVol,
+		DTE:                 dte,
+		Premium:             fillPrice * 100,      // Total for 100 shares
+		IntrinsicValue:      intrinsicValue * 100, // Intrinsic for 100 shares
+		ExtrinsicValue:      extrinsicValue * 100, // Extrinsic for 100 shares
+		PremiumPercent:      premiumPercent,       // Based on extrinsic
+		AnnualizedReturn:    annualizedReturn,     // Based on extrinsic
+		CapitalRequired:     strike * 100,         // For cash-secured put
+		POP:                 probs.Profit,
+		ProbAssignment:      probs.Assignment,
+		ProbTouch:           probs.Touch,
+		Efficiency:          annualizedReturn * probs.Profit / 100, // Probability-weighted return
+		Commission:          commission,
+		NetPremium:          netPremium,
+		NetAnnualizedReturn: netAnnualizedReturn,
+		NetEfficiency:       netAnnualizedReturn * probs.Profit / 100,
+		IsITM:               isITM,
+		Breakeven:           Breakeven(params.Right, strike, fillPrice),
+	}, true
+}
+
+// selectExpiryMonths returns up to count option months that haven't ended by now, nearest first.
+// Months starting beyond maxDTE days are dropped when maxDTE > 0. A count of 0 means front month only.
+func selectExpiryMonths(months []string, count, maxDTE int, now time.Time) []string {
+	type expiryMonth struct {
+		month string
+		start time.Time
+	}
+
+	if count <= 0 {
+		count = 1
+	}
+
+	var candidates []expiryMonth
+
+	for _, month := range months {
+		monthStart, err := parseMonthString(month)
+		if err != nil {
+			continue
+		}
+
+		// Weeklies keep a month alive until its last day, not just its third Friday
+		monthEnd := monthStart.AddDate(0, 1, 0)
+		if !monthEnd.After(now) {
+			continue
+		}
+		if maxDTE > 0 && monthStart.After(now.AddDate(0, 0, maxDTE)) {
+			continue
+		}
+
+		candidates = append(candidates, expiryMonth{month: month, start: monthStart})
+	}
+
+	// Sort by date ascending
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].start.Before(candidates[j].start)
+	})
+
+	// Take first N
+	result := []string{}
+	for i := 0; i < count && i < len(candidates); i++ {
+		result = append(result, candidates[i].month)
+	}
+
+	return result
+}
+
+// forEach calls fn for every index in [0, n) using at most workers goroutines
+func forEach(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"mnmlsm/web"
+)
+
+// PortfolioSnapshot is the capital picture that elimination and position sizing work from
+type PortfolioSnapshot struct {
+	NetWorth  float64
+	DryPowder float64
+
+	// Cash reserve (rules 5 and 14)
+	VIX            float64
+	VIXRegime      string  // Regime of the VIX tier in effect ("" if VIX deployment is off)
+	ReservePercent float64 // % of net worth kept in cash
+	Reserve        float64 // Cash kept in reserve
+	Deployable     float64 // Dry powder above the reserve
+
+	StockCapital map[string]float64 // Cost basis of open stock positions by symbol
+	PutCapital   map[string]float64 // Capital securing open puts by symbol
+}
+
+// LoadPortfolioSnapshot loads net worth, dry powder, positions and the cash reserve from the data files
+func LoadPortfolioSnapshot(rules web.TradingRules) (*PortfolioSnapshot, error) {
+	netWorth, err := calculateTotalNetWorth()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate total net worth: %w", err)
+	}
+
+	dryPowder, err := getDryPowder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dry powder: %w", err)
+	}
+
+	// Cash reserve scales with the latest VIX
+	vix := web.LoadVIX("data/vix.csv")
+	reservePercent := rules.ReservePercent(vix)
+	reserve := netWorth * reservePercent / 100
+
+	vixRegime := ""
+	if rules.Enabled(web.RuleVIXDeployment) {
+		if tier, ok := rules.VIXTier(vix); ok {
+			vixRegime = tier.Regime
+		}
+	}
+
+	return &PortfolioSnapshot{
+		NetWorth:       netWorth,
+		DryPowder:      dryPowder,
+		VIX:            vix,
+		VIXRegime:      vixRegime,
+		ReservePercent: reservePercent,
+		Reserve:        reserve,
+		Deployable:     math.Max(0, dryPowder-reserve),
+		StockCapital:   getCurrentStockPositions(),
+		PutCapital:     getCurrentPutPositions(),
+	}, nil
+}
+
+// ExistingCapital returns the capital already deployed in a symbol (stock and puts)
+func (p *PortfolioSnapshot) ExistingCapital(symbol string) float64 {
+	return p.StockCapital[symbol] + p.PutCapital[symbol]
+}
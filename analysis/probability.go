@@ -0,0 +1,162 @@
+package analysis
+
+import "math"
+
+// RiskFreeRate is the annual risk-free rate used as the drift of the lognormal model
+const RiskFreeRate = 0.04
+
+// Probabilities holds model-based outcome probabilities for a short option, as percentages
+type Probabilities struct {
+	Profit     float64 // Expiring on the profitable side of breakeven
+	Assignment float64 // Expiring in-the-money
+	Touch      float64 // Underlying trading through the strike at any time before expiry
+}
+
+// CalculateProbabilities computes outcome probabilities for selling an option from a
+// lognormal model of the underlying, using the contract's implied volatility and DTE.
+// premium is the per-share premium received. If no implied volatility is available it
+// falls back to delta-based approximations.
+func CalculateProbabilities(right string, spot, strike, premium, impliedVol, delta float64, dte int) Probabilities {
+	vol := NormalizeIV(impliedVol)
+	t := yearsToExpiry(dte)
+
+	if vol <= 0 || spot <= 0 || strike <= 0 {
+		return deltaProbabilities(delta)
+	}
+
+	var probs Probabilities
+	if right == "P" {
+		// Short put profits while the stock stays above strike - premium
+		breakeven := strike - premium
+		if breakeven <= 0 {
+			probs.Profit = 100
+		} else {
+			probs.Profit = probabilityAbove(spot, breakeven, vol, t) * 100
+		}
+		probs.Assignment = (1 - probabilityAbove(spot, strike, vol, t)) * 100
+	} else {
+		// Short call profits while the stock stays below strike + premium
+		breakeven := strike + premium
+		probs.Profit = (1 - probabilityAbove(spot, breakeven, vol, t)) * 100
+		probs.Assignment = probabilityAbove(spot, strike, vol, t) * 100
+	}
+	// An in-the-money strike has already been touched
+	if (right == "P" && spot <= strike) || (right != "P" && spot >= strike) {
+		probs.Touch = 100
+	} else {
+		probs.Touch = probabilityOfTouch(spot, strike, vol, t) * 100
+	}
+
+	return probs
+}
+
+// ModelDelta returns the Black-Scholes delta of an option, or 0 if no implied volatility is available
+func ModelDelta(right string, spot, strike, impliedVol float64, dte int) float64 {
+	vol := NormalizeIV(impliedVol)
+	if vol <= 0 || spot <= 0 || strike <= 0 {
+		return 0
+	}
+
+	t := yearsToExpiry(dte)
+	d1 := (math.Log(spot/strike) + (RiskFreeRate+0.5*vol*vol)*t) / (vol * math.Sqrt(t))
+
+	if right == "P" {
+		return normCDF(d1) - 1
+	}
+	return normCDF(d1)
+}
+
+// ModelPrice returns the Black-Scholes value per share of an option, or its intrinsic value
+// if no implied volatility is available
+func ModelPrice(right string, spot, strike, impliedVol float64, dte int) float64 {
+	intrinsic := math.Max(spot-strike, 0)
+	if right == "P" {
+		intrinsic = math.Max(strike-spot, 0)
+	}
+	vol := NormalizeIV(impliedVol)
+	if vol <= 0 || spot <= 0 || strike <= 0 {
+		return intrinsic
+	}
+
+	t := yearsToExpiry(dte)
+	sqrtT := vol * math.Sqrt(t)
+	d1 := (math.Log(spot/strike) + (RiskFreeRate+0.5*vol*vol)*t) / sqrtT
+	d2 := d1 - sqrtT
+	discount := math.Exp(-RiskFreeRate * t)
+
+	if right == "P" {
+		return strike*discount*normCDF(-d2) - spot*normCDF(-d1)
+	}
+	return spot*normCDF(d1) - strike*discount*normCDF(d2)
+}
+
+// NormalizeIV converts an implied volatility to a decimal (0.45 = 45%).
+// IBKR reports IV either as a decimal or as a percentage depending on the field format.
+func NormalizeIV(iv float64) float64 {
+	if iv > 5 {
+		return iv / 100
+	}
+	return iv
+}
+
+// yearsToExpiry converts days to expiration to years, with a floor of half a day
+// so same-day expiries don't collapse the model
+func yearsToExpiry(dte int) float64 {
+	days := float64(dte)
+	if days < 0.5 {
+		days = 0.5
+	}
+	return days / 365
+}
+
+// normCDF is the standard normal cumulative distribution function
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// probabilityAbove returns the probability that the underlying finishes above level at expiry
+func probabilityAbove(spot, level, vol, t float64) float64 {
+	if level <= 0 {
+		return 1
+	}
+	sqrtT := vol * math.Sqrt(t)
+	d2 := (math.Log(spot/level) + (RiskFreeRate-0.5*vol*vol)*t) / sqrtT
+	return normCDF(d2)
+}
+
+// probabilityOfTouch returns the probability that the underlying reaches barrier at any
+// point before expiry (first passage of a Brownian motion with drift)
+func probabilityOfTouch(spot, barrier, vol, t float64) float64 {
+	if barrier <= 0 {
+		return 0
+	}
+	b := math.Log(barrier / spot)
+	if b == 0 {
+		return 1
+	}
+
+	mu := RiskFreeRate - 0.5*vol*vol
+	sqrtT := vol * math.Sqrt(t)
+	reflection := math.Exp(2 * mu * b / (vol * vol))
+
+	var p float64
+	if b < 0 {
+		// Barrier below spot: probability the running minimum hits it
+		p = normCDF((b-mu*t)/sqrtT) + reflection*normCDF((b+mu*t)/sqrtT)
+	} else {
+		// Barrier above spot: probability the running maximum hits it
+		p = normCDF((-b+mu*t)/sqrtT) + reflection*normCDF((-b-mu*t)/sqrtT)
+	}
+
+	return math.Min(1, math.Max(0, p))
+}
+
+// deltaProbabilities approximates outcome probabilities from delta alone
+func deltaProbabilities(delta float64) Probabilities {
+	d := math.Min(1, math.Abs(delta))
+	return Probabilities{
+		Profit:     (1 - d) * 100,
+		Assignment: d * 100,
+		Touch:      math.Min(1, 2*d) * 100,
+	}
+}
@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mnmlsm/ibkr"
+)
+
+// MarketDataProvider supplies the market data the scanner needs.
+// Implemented by the IBKR gateway, saved chain snapshots and in-memory data.
+type MarketDataProvider interface {
+	// LookupUnderlying resolves a stock symbol on an exchange to its ConID
+	LookupUnderlying(symbol, exchange string) (int, error)
+	// LastPrice returns the latest price of a security
+	LastPrice(conID int) (float64, error)
+	// Expirations returns the option months available for an underlying (e.g., "NOV26")
+	Expirations(conID int) ([]string, error)
+	// Strikes returns every listed strike for an option month and right
+	Strikes(conID int, month, right string) ([]float64, error)
+	// Contracts returns the contract definitions for one strike of an option month
+	Contracts(conID int, month string, strike float64, right string) ([]ibkr.ContractInfo, error)
+	// OptionQuote returns bid/ask and greeks for an option contract
+	OptionQuote(conID int) (*ibkr.OptionPricing, error)
+}
+
+// BarProvider is implemented by market data providers with daily price history
+type BarProvider interface {
+	// DailyBars returns up to count daily bars of a security, oldest first
+	DailyBars(conID int, count int) ([]ibkr.Bar, error)
+}
+
+// EventProvider is implemented by market data providers with a corporate event calendar
+type EventProvider interface {
+	// UpcomingEvents returns the next earnings release and ex-dividend date of a security
+	UpcomingEvents(conID int) (*ibkr.UpcomingEvents, error)
+}
+
+// ReplayProvider is implemented by market data providers replaying data captured earlier
+type ReplayProvider interface {
+	// AsOf returns when the market data was captured (zero = not a replay)
+	AsOf() time.Time
+}
+
+// marketTime returns when a provider's market data is from: the capture time of a replay,
+// otherwise now
+func marketTime(provider MarketDataProvider) time.Time {
+	if replay, ok := provider.(ReplayProvider); ok {
+		if asOf := replay.AsOf(); !asOf.IsZero() {
+			return asOf
+		}
+	}
+	return time.Now()
+}
+
+// isReplay reports whether a provider replays market data captured earlier rather than live
+func isReplay(provider MarketDataProvider) bool {
+	replay, ok := provider.(ReplayProvider)
+	return ok && !replay.AsOf().IsZero()
+}
+
+// IBKRProvider serves market data from the IBKR Client Portal Gateway. The client holds every
+// HTTP request to the gateway's rate limit, so any number of workers can share a provider.
+type IBKRProvider struct {
+	client *ibkr.Client
+
+	mu     sync.Mutex
+	months map[int][]string // Option months returned by the underlying search, by ConID
+}
+
+// NewIBKRProvider creates a market data provider backed by an IBKR client
+func NewIBKRProvider(client *ibkr.Client) *IBKRProvider {
+	return &IBKRProvider{
+		client: client,
+		months: make(map[int][]string),
+	}
+}
+
+// LookupUnderlying searches for the underlying and remembers its option months
+func (p *IBKRProvider) LookupUnderlying(symbol, exchange string) (int, error) {
+	conID, months, err := p.client.SearchUnderlying(symbol, exchange)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	p.months[conID] = months
+	p.mu.Unlock()
+
+	return conID, nil
+}
+
+// LastPrice fetches the current price for a security
+func (p *IBKRProvider) LastPrice(conID int) (float64, error) {
+	return p.client.GetLastPrice(conID)
+}
+
+// Expirations returns the option months found when the underlying was looked up
+func (p *IBKRProvider) Expirations(conID int) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	months, ok := p.months[conID]
+	if !ok {
+		return nil, fmt.Errorf("underlying %d has not been looked up", conID)
+	}
+	return months, nil
+}
+
+// Strikes fetches the strikes for an option month
+func (p *IBKRProvider) Strikes(conID int, month, right string) ([]float64, error) {
+	strikes, err := p.client.GetAllStrikes(conID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	if right == "C" {
+		return strikes.Call, nil
+	}
+	return strikes.Put, nil
+}
+
+// Contracts fetches contract definitions for one strike
+func (p *IBKRProvider) Contracts(conID int, month string, strike float64, right string) ([]ibkr.ContractInfo, error) {
+	return p.client.GetContractInfo(conID, month, fmt.Sprintf("%.2f", strike), right)
+}
+
+// OptionQuote fetches bid/ask and greeks for an option contract
+func (p *IBKRProvider) OptionQuote(conID int) (*ibkr.OptionPricing, error) {
+	return p.client.GetOptionPricing(conID)
+}
+
+// DailyBars fetches enough calendar history from the gateway for count trading days
+func (p *IBKRProvider) DailyBars(conID int, count int) ([]ibkr.Bar, error) {
+	bars, err := p.client.GetDailyBars(conID, fmt.Sprintf("%dd", count*7/5+10))
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > count {
+		bars = bars[len(bars)-count:]
+	}
+	return bars, nil
+}
+
+// UpcomingEvents fetches the next earnings release and ex-dividend date from the gateway
+func (p *IBKRProvider) UpcomingEvents(conID int) (*ibkr.UpcomingEvents, error) {
+	return p.client.GetUpcomingEvents(conID)
+}
+
+// OpenProvider returns a provider for a saved chain when snapshotPath is set,
+// otherwise a live provider backed by the IBKR gateway
+func OpenProvider(snapshotPath string) (MarketDataProvider, error) {
+	if snapshotPath != "" {
+		return LoadSnapshot(snapshotPath)
+	}
+	return NewIBKRProvider(ibkr.NewClient()), nil
+}
@@ -0,0 +1,369 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"mnmlsm/ibkr"
+)
+
+// MemoryProvider serves market data held in memory.
+// It backs saved chain snapshots, backtests and tests that run without a gateway.
+type MemoryProvider struct {
+	mu          sync.RWMutex
+	underlyings []*SnapshotUnderlying
+	byConID     map[int]*SnapshotUnderlying
+	options     map[int]*SnapshotOption // Option ConID -> option
+	asOf        time.Time               // When the data was captured (zero = not a replay)
+}
+
+// NewMemoryProvider creates an empty in-memory market data provider
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		byConID: make(map[int]*SnapshotUnderlying),
+		options: make(map[int]*SnapshotOption),
+	}
+}
+
+// SetUnderlying adds or updates an underlying stock
+func (m *MemoryProvider) SetUnderlying(symbol, exchange string, conID int, price float64, months []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.underlying(conID)
+	u.Symbol = symbol
+	u.Exchange = exchange
+	if price > 0 {
+		u.Price = price
+	}
+	if months != nil {
+		u.Months = months
+	}
+}
+
+// AddOption adds or replaces an option contract (and its quote) on an underlying
+func (m *MemoryProvider) AddOption(underlyingConID int, option SnapshotOption) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.underlying(underlyingConID)
+	if existing, ok := m.options[option.ConID]; ok {
+		*existing = option
+		return
+	}
+
+	u.Options = append(u.Options, option)
+
+	// Appending may have moved the slice, so re-point the index at its elements
+	for i := range u.Options {
+		m.options[u.Options[i].ConID] = &u.Options[i]
+	}
+}
+
+// SetBars replaces the daily price history of an underlying
+func (m *MemoryProvider) SetBars(conID int, bars []ibkr.Bar) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.underlying(conID).Bars = append([]ibkr.Bar(nil), bars...)
+}
+
+// SetAsOf records when the held market data was captured, so scans count DTEs from then
+func (m *MemoryProvider) SetAsOf(asOf time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.asOf = asOf
+}
+
+// AsOf returns when the held market data was captured (zero = not set)
+func (m *MemoryProvider) AsOf() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.asOf
+}
+
+// SetQuote updates the quote of an option that was already added
+func (m *MemoryProvider) SetQuote(conID int, pricing ibkr.OptionPricing) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if option, ok := m.options[conID]; ok {
+		option.setPricing(pricing)
+	}
+}
+
+// Snapshot returns a copy of everything held by the provider
+func (m *MemoryProvider) Snapshot() Snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := Snapshot{}
+	for _, u := range m.underlyings {
+		c := *u
+		c.Months = append([]string(nil), u.Months...)
+		c.Options = append([]SnapshotOption(nil), u.Options...)
+		c.Bars = append([]ibkr.Bar(nil), u.Bars...)
+		snapshot.Underlyings = append(snapshot.Underlyings, c)
+	}
+	return snapshot
+}
+
+// underlying returns the underlying for a ConID, creating it if needed. Callers hold the lock.
+func (m *MemoryProvider) underlying(conID int) *SnapshotUnderlying {
+	if u, ok := m.byConID[conID]; ok {
+		return u
+	}
+	u := &SnapshotUnderlying{ConID: conID}
+	m.underlyings = append(m.underlyings, u)
+	m.byConID[conID] = u
+	return u
+}
+
+// LookupUnderlying finds an underlying by symbol, matching the exchange when both sides have one
+func (m *MemoryProvider) LookupUnderlying(symbol, exchange string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.underlyings {
+		if u.Symbol != symbol {
+			continue
+		}
+		if exchange == "" || u.Exchange == "" || u.Exchange == exchange {
+			return u.ConID, nil
+		}
+	}
+	return 0, fmt.Errorf("no options found for %s on %s", symbol, exchange)
+}
+
+// LastPrice returns the stored price of an underlying
+func (m *MemoryProvider) LastPrice(conID int) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.byConID[conID]
+	if !ok || u.Price <= 0 {
+		return 0, fmt.Errorf("no price data for conid %d", conID)
+	}
+	return u.Price, nil
+}
+
+// Expirations returns the stored option months of an underlying
+func (m *MemoryProvider) Expirations(conID int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.byConID[conID]
+	if !ok {
+		return nil, fmt.Errorf("unknown underlying conid %d", conID)
+	}
+	return u.Months, nil
+}
+
+// Strikes returns the distinct strikes stored for an option month and right
+func (m *MemoryProvider) Strikes(conID int, month, right string) ([]float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.byConID[conID]
+	if !ok {
+		return nil, fmt.Errorf("unknown underlying conid %d", conID)
+	}
+
+	seen := make(map[float64]bool)
+	var strikes []float64
+	for _, option := range u.Options {
+		if option.Month != month || option.Right != right || seen[option.Strike] {
+			continue
+		}
+		seen[option.Strike] = true
+		strikes = append(strikes, option.Strike)
+	}
+	sort.Float64s(strikes)
+
+	return strikes, nil
+}
+
+// Contracts returns the stored contract definitions for one strike
+func (m *MemoryProvider) Contracts(conID int, month string, strike float64, right string) ([]ibkr.ContractInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.byConID[conID]
+	if !ok {
+		return nil, fmt.Errorf("unknown underlying conid %d", conID)
+	}
+
+	var contracts []ibkr.ContractInfo
+	for _, option := range u.Options {
+		if option.Month == month && option.Right == right && math.Abs(option.Strike-strike) < 0.001 {
+			contracts = append(contracts, option.contractInfo(u))
+		}
+	}
+	return contracts, nil
+}
+
+// OptionQuote returns the stored quote of an option contract
+func (m *MemoryProvider) OptionQuote(conID int) (*ibkr.OptionPricing, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	option, ok := m.options[conID]
+	if !ok {
+		return nil, fmt.Errorf("no pricing data returned for conid %d", conID)
+	}
+	pricing := option.pricing()
+	return &pricing, nil
+}
+
+// DailyBars returns the last count stored daily bars of an underlying
+func (m *MemoryProvider) DailyBars(conID int, count int) ([]ibkr.Bar, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.byConID[conID]
+	if !ok || len(u.Bars) == 0 {
+		return nil, fmt.Errorf("no price history for conid %d", conID)
+	}
+	bars := u.Bars
+	if len(bars) > count {
+		bars = bars[len(bars)-count:]
+	}
+	return append([]ibkr.Bar(nil), bars...), nil
+}
+
+// RecordingProvider passes requests through to another provider and keeps a copy
+// of every response, so a live scan can be saved as a snapshot and replayed later
+type RecordingProvider struct {
+	source   MarketDataProvider
+	recorded *MemoryProvider
+
+	mu      sync.Mutex
+	lookups map[int][2]string // Underlying ConID -> symbol, exchange
+}
+
+// NewRecordingProvider wraps source so its responses are recorded
+func NewRecordingProvider(source MarketDataProvider) *RecordingProvider {
+	recorded := NewMemoryProvider()
+	if replay, ok := source.(ReplayProvider); ok {
+		recorded.SetAsOf(replay.AsOf())
+	}
+	return &RecordingProvider{
+		source:   source,
+		recorded: recorded,
+		lookups:  make(map[int][2]string),
+	}
+}
+
+// Recorded returns the provider holding everything recorded so far
+func (r *RecordingProvider) Recorded() *MemoryProvider {
+	return r.recorded
+}
+
+// AsOf returns when the source's market data was captured (zero = live)
+func (r *RecordingProvider) AsOf() time.Time {
+	return r.recorded.AsOf()
+}
+
+// LookupUnderlying records the underlying's symbol and exchange
+func (r *RecordingProvider) LookupUnderlying(symbol, exchange string) (int, error) {
+	conID, err := r.source.LookupUnderlying(symbol, exchange)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	r.lookups[conID] = [2]string{symbol, exchange}
+	r.mu.Unlock()
+
+	r.recorded.SetUnderlying(symbol, exchange, conID, 0, nil)
+	return conID, nil
+}
+
+// LastPrice records the price of looked-up underlyings
+func (r *RecordingProvider) LastPrice(conID int) (float64, error) {
+	price, err := r.source.LastPrice(conID)
+	if err != nil {
+		return 0, err
+	}
+
+	if lookup, ok := r.lookup(conID); ok {
+		r.recorded.SetUnderlying(lookup[0], lookup[1], conID, price, nil)
+	}
+	return price, nil
+}
+
+// Expirations records the option months of an underlying
+func (r *RecordingProvider) Expirations(conID int) ([]string, error) {
+	months, err := r.source.Expirations(conID)
+	if err != nil {
+		return nil, err
+	}
+
+	if lookup, ok := r.lookup(conID); ok {
+		r.recorded.SetUnderlying(lookup[0], lookup[1], conID, 0, months)
+	}
+	return months, nil
+}
+
+// Strikes passes through; recorded strikes are derived from recorded contracts
+func (r *RecordingProvider) Strikes(conID int, month, right string) ([]float64, error) {
+	return r.source.Strikes(conID, month, right)
+}
+
+// Contracts records contract definitions under their option month
+func (r *RecordingProvider) Contracts(conID int, month string, strike float64, right string) ([]ibkr.ContractInfo, error) {
+	contracts, err := r.source.Contracts(conID, month, strike, right)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, contract := range contracts {
+		option := SnapshotOption{
+			Month:        month,
+			ConID:        contract.ConID,
+			Strike:       strike,
+			Right:        right,
+			MaturityDate: contract.MaturityDate,
+		}
+		r.recorded.AddOption(conID, option)
+	}
+	return contracts, nil
+}
+
+// OptionQuote records the quote on the previously recorded contract
+func (r *RecordingProvider) OptionQuote(conID int) (*ibkr.OptionPricing, error) {
+	pricing, err := r.source.OptionQuote(conID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorded.SetQuote(conID, *pricing)
+	return pricing, nil
+}
+
+// DailyBars records the price history of an underlying when the source has one
+func (r *RecordingProvider) DailyBars(conID int, count int) ([]ibkr.Bar, error) {
+	source, ok := r.source.(BarProvider)
+	if !ok {
+		return nil, fmt.Errorf("no price history available")
+	}
+	bars, err := source.DailyBars(conID, count)
+	if err != nil {
+		return nil, err
+	}
+
+	r.recorded.SetBars(conID, bars)
+	return bars, nil
+}
+
+func (r *RecordingProvider) lookup(conID int) ([2]string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lookup, ok := r.lookups[conID]
+	return lookup, ok
+}
@@ -0,0 +1,250 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// Roll window and strike reach from rule 13 ("Roll for credit when possible (14-30 days out, lower strike)")
+const (
+	DefaultRollMinDTE      = 14
+	DefaultRollMaxDTE      = 30
+	DefaultRollStrikeRange = 0.10 // How far past the current strike to look, as a fraction of it
+)
+
+// RollParams defines parameters for finding rolls of an open option position
+type RollParams struct {
+	Position    web.OptionPosition
+	Exchange    string  // Exchange (defaults to "NASDAQ")
+	MinDTE      int     // Earliest new expiry in days (0 = DefaultRollMinDTE)
+	MaxDTE      int     // Latest new expiry in days (0 = DefaultRollMaxDTE)
+	StrikeRange float64 // Reach past the current strike as a fraction of it (0 = DefaultRollStrikeRange)
+	Workers     int     // Concurrent requests (0 = DefaultWorkers)
+
+	// Fill assumptions and fees, as in ScanParams
+	MinOpenInterest  int
+	MaxSpreadPercent float64
+	FillMode         string
+	FillFraction     float64
+	Commissions      *web.CommissionSchedule
+}
+
+// RollAnalysis is the current state of a position and its ranked roll candidates
+type RollAnalysis struct {
+	Position        web.OptionPosition
+	Right           string // "C" or "P"
+	UnderlyingPrice float64
+	CurrentDTE      int
+	BuyBackPrice    float64 // Per share, at the assumed fill
+	BuyBackCost     float64 // Per contract, including commission and fees
+	Candidates      []RollCandidate
+}
+
+// RollCandidate is one roll combination: buy back the current contract and sell New.
+// Dollar amounts are per contract.
+type RollCandidate struct {
+	New              OptionContract
+	NewPremium       float64 // Credit from selling the new contract at the assumed fill
+	Commission       float64 // Commission and fees for both legs
+	NetCredit        float64 // NewPremium - buy-back cost - commission (negative = debit)
+	Breakeven        float64 // Breakeven of the rolled position, counting all premium collected so far
+	AddedDays        int     // Days added to the position
+	AnnualizedReturn float64 // Annualized % of NetCredit on the capital at risk over the added days
+}
+
+// FindRolls prices the buy-back of an open position and ranks rolls to later expiries
+// at the same or safer strikes (lower for puts, higher for calls)
+func (s *Scanner) FindRolls(params RollParams) (*RollAnalysis, error) {
+	pos := params.Position
+	if pos.Status != "Open" {
+		return nil, fmt.Errorf("position %s is %s, not open", pos.PositionID, strings.ToLower(pos.Status))
+	}
+
+	right := "C"
+	if pos.OptionType == "Put" {
+		right = "P"
+	}
+
+	expiry, err := time.Parse("2006-01-02", pos.Expiry)
+	if err != nil {
+		return nil, fmt.Errorf("parsing expiry %q: %w", pos.Expiry, err)
+	}
+
+	exchange := params.Exchange
+	if exchange == "" {
+		exchange = "NASDAQ"
+	}
+
+	minDTE := params.MinDTE
+	if minDTE <= 0 {
+		minDTE = DefaultRollMinDTE
+	}
+	maxDTE := params.MaxDTE
+	if maxDTE <= 0 {
+		maxDTE = DefaultRollMaxDTE
+	}
+	reach := params.StrikeRange
+	if reach <= 0 {
+		reach = DefaultRollStrikeRange
+	}
+
+	// 1. Price the buy-back of the current contract
+	conID, err := s.provider.LookupUnderlying(pos.Symbol, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("searching underlying: %w", err)
+	}
+
+	currentPrice, err := s.provider.LastPrice(conID)
+	if err != nil {
+		return nil, fmt.Errorf("getting current price: %w", err)
+	}
+
+	current, err := s.findContract(conID, expiry, pos.Strike, right)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := s.provider.OptionQuote(current.ConID)
+	if err != nil {
+		return nil, fmt.Errorf("pricing current contract: %w", err)
+	}
+
+	buyBackPrice := BuyFillPrice(pricing.Bid, pricing.Ask, params.FillMode, params.FillFraction)
+	if buyBackPrice <= 0 {
+		return nil, fmt.Errorf("no quote for current contract %d", current.ConID)
+	}
+
+	var buyBackCommission float64
+	if params.Commissions != nil {
+		buyBackCommission = params.Commissions.OptionCommission(1, buyBackPrice, false)
+	}
+
+	roll := &RollAnalysis{
+		Position:        pos,
+		Right:           right,
+		UnderlyingPrice: currentPrice,
+		CurrentDTE:      daysToExpiry(current.MaturityDate, marketTime(s.provider)),
+		BuyBackPrice:    buyBackPrice,
+		BuyBackCost:     buyBackPrice*100 + buyBackCommission,
+	}
+
+	// 2. Scan the roll window, wide enough to reach both the current price and the strike
+	result, err := s.scanSymbol(ScanParams{
+		Symbol:           pos.Symbol,
+		Exchange:         exchange,
+		Right:            right,
+		StrikeMode:       StrikeModeDollar,
+		StrikeRange:      math.Abs(currentPrice-pos.Strike) + pos.Strike*reach,
+		MinDTE:           minDTE,
+		MaxDTE:           maxDTE,
+		NumExpiries:      maxDTE/30 + 2, // Every month that can hold an expiry in the window
+		Workers:          params.Workers,
+		MinOpenInterest:  params.MinOpenInterest,
+		MaxSpreadPercent: params.MaxSpreadPercent,
+		FillMode:         params.FillMode,
+		FillFraction:     params.FillFraction,
+		Commissions:      params.Commissions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning roll candidates: %w", err)
+	}
+
+	// 3. Combine each candidate with the buy-back
+	// Premium already banked on the position, per contract
+	banked := 0.0
+	if pos.Contracts > 0 {
+		banked = pos.NetPremium / float64(pos.Contracts)
+	}
+
+	for _, c := range result.Contracts {
+		if c.ConID == current.ConID || c.DTE <= roll.CurrentDTE {
+			continue
+		}
+		if right == "P" && (c.Strike > pos.Strike || c.Strike < pos.Strike*(1-reach)) {
+			continue
+		}
+		if right == "C" && (c.Strike < pos.Strike || c.Strike > pos.Strike*(1+reach)) {
+			continue
+		}
+
+		commission := buyBackCommission + c.Commission
+		netCredit := c.Premium - buyBackPrice*100 - commission
+
+		// All premium kept per share moves the breakeven away from the strike
+		kept := (banked + netCredit) / 100
+		breakeven := c.Strike - kept
+		capital := c.Strike * 100
+		if right == "C" {
+			breakeven = c.Strike + kept
+			if pos.Contracts > 0 && pos.Capital > 0 {
+				capital = pos.Capital / float64(pos.Contracts)
+			}
+		}
+
+		addedDays := c.DTE - roll.CurrentDTE
+
+		roll.Candidates = append(roll.Candidates, RollCandidate{
+			New:              c,
+			NewPremium:       c.Premium,
+			Commission:       commission,
+			NetCredit:        netCredit,
+			Breakeven:        breakeven,
+			AddedDays:        addedDays,
+			AnnualizedReturn: CalculateAnnualizedReturn(netCredit, capital, addedDays),
+		})
+	}
+
+	SortRolls(roll.Candidates, right, "credit")
+	return roll, nil
+}
+
+// SortRolls ranks roll candidates by "credit" (default), "breakeven", "days" or "return"
+func SortRolls(candidates []RollCandidate, right, by string) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch by {
+		case "breakeven":
+			// Further from the stock is safer: lower for puts, higher for calls
+			if right == "P" {
+				return a.Breakeven < b.Breakeven
+			}
+			return a.Breakeven > b.Breakeven
+		case "days":
+			return a.AddedDays < b.AddedDays
+		case "return":
+			return a.AnnualizedReturn > b.AnnualizedReturn
+		default:
+			return a.NetCredit > b.NetCredit
+		}
+	})
+}
+
+// findContract resolves the contract of a position from its expiry, strike and right
+func (s *Scanner) findContract(conID int, expiry time.Time, strike float64, right string) (*OptionContract, error) {
+	month := strings.ToUpper(expiry.Format("Jan06"))
+	maturity := expiry.Format("20060102")
+
+	contracts, err := s.provider.Contracts(conID, month, strike, right)
+	if err != nil {
+		return nil, fmt.Errorf("getting current contract: %w", err)
+	}
+
+	for _, contract := range contracts {
+		if contract.MaturityDate == maturity {
+			return &OptionContract{
+				Symbol:       contract.Symbol,
+				Strike:       strike,
+				Right:        right,
+				MaturityDate: contract.MaturityDate,
+				ConID:        contract.ConID,
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no %s %s $%.2f contract expiring %s", month, right, strike, maturity)
+}
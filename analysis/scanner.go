@@ -4,182 +4,42 @@ import (
 	"encoding/csv"
 	"fmt"
 	"math"
-	"mnmlsm/ibkr"
 	"os"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 )
 
-// Scanner performs options premium scanning using an IBKR client
+// Scanner performs options premium scanning against a market data provider
 type Scanner struct {
-	client *ibkr.Client
+	provider MarketDataProvider
 }
 
 // NewScanner creates a new premium scanner
-func NewScanner(client *ibkr.Client) *Scanner {
+func NewScanner(provider MarketDataProvider) *Scanner {
 	return &Scanner{
-		client: client,
+		provider: provider,
 	}
 }
 
 // ScanPremiums scans for option premium opportunities based on given parameters
 // Returns a list of OptionContracts that meet the criteria
 func (s *Scanner) ScanPremiums(params ScanParams) ([]OptionContract, error) {
-	// 1. Search for underlying and get option months
-	conID, months, err := s.client.SearchUnderlying(params.Symbol, params.Exchange)
+	result, err := s.scanSymbol(params)
 	if err != nil {
-		return nil, fmt.Errorf("searching underlying: %w", err)
-	}
-
-	// 2. Get current stock price
-	currentPrice, err := s.client.GetLastPrice(conID)
-	if err != nil {
-		return nil, fmt.Errorf("getting current price: %w", err)
-	}
-
-	// 3. Use the first (front) month for scanning
-	if len(months) == 0 {
-		return nil, fmt.Errorf("no option months available")
-	}
-	month := months[0]
-
-	// 4. Scan the front month for qualifying contracts
-	var qualifyingContracts []OptionContract
-
-	// Get strikes for this month
-	strikes, err := s.client.GetStrikes(conID, month, currentPrice, params.StrikeRange)
-	if err != nil {
-		return nil, fmt.Errorf("getting strikes: %w", err)
-	}
-
-	// Process each strike
-	for _, strike := range strikes {
-		strikeStr := fmt.Sprintf("%.2f", strike)
-
-		// Get contract info
-		contracts, err := s.client.GetContractInfo(conID, month, strikeStr, params.Right)
-		if err != nil {
-			continue // Skip strikes with errors
-		}
-
-		// Rate limiting
-		time.Sleep(20 * time.Millisecond) // 50 req/s rate limit (60 max)
-
-		// Process each contract (usually multiple expiries per strike/month)
-		for _, contract := range contracts {
-			// Calculate DTE first to filter
-			dte := CalculateDaysToExpiry(contract.MaturityDate)
-
-			// Skip if beyond max DTE
-			if params.MaxDTE > 0 && dte > params.MaxDTE {
-				continue
-			}
-
-			// Get pricing and greeks
-			pricing, err := s.client.GetOptionPricing(contract.ConID)
-			if err != nil {
-				continue // Skip contracts with pricing errors
-			}
-
-			// Rate limiting
-			time.Sleep(20 * time.Millisecond) // 50 req/s rate limit (60 max)
-
-			// Skip if no valid bid or ask
-			if pricing.Bid <= 0 && pricing.Ask <= 0 {
-				continue
-			}
-
-			// Calculate mid price (or use bid/ask if one is missing)
-			midPrice := pricing.Bid
-			if pricing.Ask > 0 {
-				if pricing.Bid > 0 {
-					midPrice = (pricing.Bid + pricing.Ask) / 2
-				} else {
-					midPrice = pricing.Ask
-				}
-			}
-
-			// Calculate intrinsic and extrinsic value
-			var intrinsicValue float64
-			var isITM bool
-
-			if params.Right == "P" {
-				// Put: intrinsic = max(0, strike - stock price)
-				intrinsicValue = math.Max(0, strike-currentPrice)
-				isITM = strike > currentPrice
-			} else {
-				// Call: intrinsic = max(0, stock price - strike)
-				intrinsicValue = math.Max(0, currentPrice-strike)
-				isITM = currentPrice > strike
-			}
-
-			// Extrinsic value (time premium) = total premium - intrinsic
-			extrinsicValue := math.Max(0, midPrice-intrinsicValue)
-
-			// Calculate metrics using EXTRINSIC VALUE (time premium only)
-			// This represents the actual return on your capital, not just ITM movement
-			premiumPercent := (extrinsicValue / strike) * 100
-			annualizedReturn := (premiumPercent / float64(dte)) * 365
-
-			// Total premium for 100 shares (for display)
-			totalPremium := midPrice * 100
-			totalExtrinsic := extrinsicValue * 100
-			totalIntrinsic := intrinsicValue * 100
-
-			// Filter by minimum return (based on extrinsic value)
-			if annualizedReturn < params.MinReturn {
-				continue
-			}
-
-			// Calculate Probability of Profit (1 - |Delta|)
-			pop := (1 - math.Abs(pricing.Delta)) * 100
-
-			// Calculate Efficiency (risk-adjusted return)
-			// Efficiency = AnnualizedReturn / (1 - POP)
-			efficiency := 0.0
-			if pop < 100 {
-				efficiency = annualizedReturn / (1 - (pop / 100))
-			}
-
-			// Build OptionContract
-			optContract := OptionContract{
-				Symbol:           params.Symbol,
-				Strike:           strike,
-				Right:            params.Right,
-				MaturityDate:     contract.MaturityDate,
-				ConID:            contract.ConID,
-				UnderlyingConID:  conID,
-				Bid:              pricing.Bid,
-				Ask:              pricing.Ask,
-				MidPrice:         midPrice,
-				UnderlyingPrice:  currentPrice,
-				Delta:            pricing.Delta,
-				Gamma:            pricing.Gamma,
-				Theta:            pricing.Theta,
-				Vega:             pricing.Vega,
-				ImpliedVol:       pricing.ImpliedVol,
-				DTE:              dte,
-				Premium:          totalPremium,    // Total for 100 shares
-				IntrinsicValue:   totalIntrinsic,  // Intrinsic for 100 shares
-				ExtrinsicValue:   totalExtrinsic,  // Extrinsic for 100 shares
-				PremiumPercent:   premiumPercent,  // Based on extrinsic
-				AnnualizedReturn: annualizedReturn, // Based on extrinsic
-				CapitalRequired:  strike * 100,     // For cash-secured put
-				POP:              pop,
-				Efficiency:       efficiency,
-				IsITM:            isITM,
-			}
-
-			qualifyingContracts = append(qualifyingContracts, optContract)
-		}
+		return nil, err
 	}
-
-	return qualifyingContracts, nil
+	return result.Contracts, nil
 }
 
 // CalculateDaysToExpiry calculates days until option expiration
 func CalculateDaysToExpiry(maturityDate string) int {
+	return daysToExpiry(maturityDate, time.Now())
+}
+
+// daysToExpiry calculates days from now until option expiration
+func daysToExpiry(maturityDate string, now time.Time) int {
 	// Parse maturity date (format: "20241220")
 	expiryTime, err := time.Parse("20060102", maturityDate)
 	if err != nil {
@@ -190,7 +50,6 @@ func CalculateDaysToExpiry(maturityDate string) int {
 	expiry := time.Date(expiryTime.Year(), expiryTime.Month(), expiryTime.Day(),
 		16, 0, 0, 0, time.FixedZone("EST", -5*3600))
 
-	now := time.Now()
 	duration := expiry.Sub(now)
 	days := int(math.Round(duration.Hours() / 24))
 
@@ -212,28 +71,32 @@ func CalculateAnnualizedReturn(premium, capitalRequired float64, days int) float
 	return annualized
 }
 
-// filterMonthsByDTE filters option months by maximum DTE
-func (s *Scanner) filterMonthsByDTE(months []string, maxDTE int) []string {
-	now := time.Now()
-	var validMonths []string
-
-	for _, month := range months {
-		// Parse month string (format: "JAN24", "FEB24", etc.)
-		monthDate, err := parseMonthString(month)
-		if err != nil {
-			continue
+// SortContracts ranks contracts in place, best first, by the given key:
+// "score" (weighted score, see Scorer), "return" (annualized return), "net" (net efficiency),
+// "pop" (probability of profit), "touch" (lowest probability of touch), "iv-hv" (options priced
+// furthest above realized volatility), "breakeven" (breakeven furthest away in expected moves)
+// or "efficiency" (the default)
+func SortContracts(contracts []OptionContract, by string) {
+	sort.SliceStable(contracts, func(i, j int) bool {
+		switch by {
+		case "return":
+			return contracts[i].AnnualizedReturn > contracts[j].AnnualizedReturn
+		case "pop":
+			return contracts[i].POP > contracts[j].POP
+		case "net":
+			return contracts[i].NetEfficiency > contracts[j].NetEfficiency
+		case "touch":
+			return contracts[i].ProbTouch < contracts[j].ProbTouch
+		case "score":
+			return contracts[i].Score > contracts[j].Score
+		case "iv-hv":
+			return contracts[i].IVHVRatio > contracts[j].IVHVRatio
+		case "breakeven":
+			return contracts[i].BreakevenMoves > contracts[j].BreakevenMoves
+		default:
+			return contracts[i].Efficiency > contracts[j].Efficiency
 		}
-
-		// Calculate DTE (third Friday of the month)
-		expiry := getThirdFriday(monthDate)
-		dte := int(math.Round(expiry.Sub(now).Hours() / 24))
-
-		if dte >= 0 && dte <= maxDTE {
-			validMonths = append(validMonths, month)
-		}
-	}
-
-	return validMonths
+	})
 }
 
 // Helper functions
@@ -277,55 +140,114 @@ func getThirdFriday(monthDate time.Time) time.Time {
 	return current.AddDate(0, 0, 14)
 }
 
-// ScanAllStocks scans all stocks from solar-system.csv and saves to options-chain.csv
+// ScanAllStocks scans all stocks from solar-system.csv and saves the contracts to params.Output
 func (s *Scanner) ScanAllStocks(params BatchScanParams) error {
+	_, err := s.scanAll(params)
+	return err
+}
+
+// scanAll runs ScanAllStocks and also returns the saved contracts
+func (s *Scanner) scanAll(params BatchScanParams) ([]OptionContract, error) {
+	start := time.Now()
+
 	// Load stocks from solar-system.csv
 	stocks, err := loadSolarSystem(params.SolarSystemCSV)
 	if err != nil {
-		return fmt.Errorf("loading solar-system.csv: %w", err)
+		return nil, fmt.Errorf("loading solar-system.csv: %w", err)
+	}
+
+	// Open the output and write its header
+	output, err := OpenResultWriter(params.OutputFormat, params.Output)
+	if err != nil {
+		return nil, fmt.Errorf("opening output: %w", err)
+	}
+	if err := output.Begin(ContractSchema); err != nil {
+		output.Close()
+		return nil, fmt.Errorf("writing output: %w", err)
 	}
 
-	// Initialize output CSV
-	if err := initializeCSV(params.OutputCSV); err != nil {
-		return fmt.Errorf("initializing CSV: %w", err)
+	workers := params.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
 	}
 
 	fmt.Printf("🪐 Scanning %d stocks from solar-system.csv\n", len(stocks))
-	fmt.Printf("   Right: %s, Min Return: %.0f%%, Expiries: %d\n\n", params.Right, params.MinReturn, params.NumExpiries)
+	fmt.Printf("   Right: %s, Min Return: %.0f%%, Expiries: %d, Workers: %d\n\n", params.Right, params.MinReturn, params.NumExpiries, workers)
 
-	totalContracts := 0
+	var contracts []OptionContract
+	scans := make([]*SymbolScan, len(stocks))
 	successCount := 0
+	completed := 0
 	failedStocks := []string{}
 
-	for i, stock := range stocks {
-		fmt.Printf("[%d/%d] Processing %s...\n", i+1, len(stocks), stock.Symbol)
+	var mu sync.Mutex
+	var writeErr error
+
+	forEach(len(stocks), workers, func(i int) {
+		stock := stocks[i]
 
 		// Scan this stock
-		contracts, err := s.scanStockMultiExpiry(stock, params)
+		result, err := s.scanSymbol(params.scanParams(stock.Symbol))
+		scans[i] = result
+
+		// Report and save results one stock at a time
+		mu.Lock()
+		defer mu.Unlock()
+
+		completed++
+		fmt.Printf("[%d/%d] %s\n", completed, len(stocks), stock.Symbol)
+
 		if err != nil {
-			fmt.Printf("   ❌ Error: %v\n", err)
+			fmt.Printf("   ❌ Error: %v\n\n", err)
 			failedStocks = append(failedStocks, fmt.Sprintf("%s: %v", stock.Symbol, err))
-			continue
+			return
+		}
+
+		printSymbolScan(result)
+		if params.Explain {
+			PrintExplain(result)
 		}
 
-		// Save all contracts to CSV
-		for _, contract := range contracts {
-			if err := appendContractToCSV(contract, params.OutputCSV); err != nil {
-				return fmt.Errorf("appending to CSV: %w", err)
+		// Save all contracts, best ranked first
+		SortContracts(result.Contracts, params.SortBy)
+		for _, contract := range result.Contracts {
+			if err := output.Write(ContractRecord(contract)); err != nil {
+				writeErr = err
+				return
 			}
-			totalContracts++
+			contracts = append(contracts, contract)
 		}
 
-		fmt.Printf("   ✅ Found %d contracts\n\n", len(contracts))
+		fmt.Printf("   ✅ Found %d contracts\n\n", len(result.Contracts))
 		successCount++
+	})
+
+	if writeErr != nil {
+		output.Close()
+		return nil, fmt.Errorf("writing output: %w", writeErr)
+	}
+	if err := output.Close(); err != nil {
+		return nil, fmt.Errorf("writing output: %w", err)
 	}
 
 	// Summary
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Printf("✨ Scan Complete!\n")
 	fmt.Printf("   Success: %d/%d stocks\n", successCount, len(stocks))
-	fmt.Printf("   Total Contracts: %d\n", totalContracts)
-	fmt.Printf("   Saved to: %s\n", params.OutputCSV)
+	fmt.Printf("   Total Contracts: %d\n", len(contracts))
+	fmt.Printf("   Saved to: %s\n", params.Output)
+
+	symbols := make([]string, len(stocks))
+	for i, stock := range stocks {
+		symbols[i] = stock.Symbol
+	}
+	// A replayed chain isn't today's market, so it stays out of the scan history, IV history
+	// and surfaces
+	if !isReplay(s.provider) {
+		params.saveHistory(ScanKindSolarSystem, start, symbols, contracts)
+		params.recordIV(scans)
+		params.recordVolSurfaces(scans)
+	}
 
 	if len(failedStocks) > 0 {
 		fmt.Printf("\n❌ Failed stocks:\n")
@@ -334,209 +256,87 @@ func (s *Scanner) ScanAllStocks(params BatchScanParams) error {
 		}
 	}
 
-	return nil
+	return contracts, nil
 }
 
-// scanStockMultiExpiry scans one stock across multiple expiries
-func (s *Scanner) scanStockMultiExpiry(stock SolarSystemStock, params BatchScanParams) ([]OptionContract, error) {
-	// Get underlying and option months
-	// Use NASDAQ as default exchange (matches ibkr-quote behavior)
-	conID, months, err := s.client.SearchUnderlying(stock.Symbol, "NASDAQ")
-	if err != nil {
-		return nil, fmt.Errorf("searching underlying: %w", err)
+// scanParams builds the single-symbol pipeline parameters for one stock of a batch scan
+func (p BatchScanParams) scanParams(symbol string) ScanParams {
+	exchange := p.Exchange
+	if exchange == "" {
+		// Use NASDAQ as default exchange (matches ibkr-quote behavior)
+		exchange = "NASDAQ"
+	}
+
+	return ScanParams{
+		Symbol:      symbol,
+		Exchange:    exchange,
+		Right:       p.Right,
+		StrikeMode:  p.StrikeMode,
+		StrikeRange: p.StrikeRange,
+		MinDelta:    p.MinDelta,
+		MaxDelta:    p.MaxDelta,
+		MinReturn:   p.MinReturn,
+		MaxDTE:      p.MaxDTE,
+		NumExpiries: p.NumExpiries,
+		Workers:     p.Workers,
+
+		MinOpenInterest:  p.MinOpenInterest,
+		MinVolume:        p.MinVolume,
+		MaxSpreadPercent: p.MaxSpreadPercent,
+		FillMode:         p.FillMode,
+		FillFraction:     p.FillFraction,
+		Commissions:      p.Commissions,
+		Events:           p.Events,
+		EarningsMode:     p.EarningsMode,
+		Sizing:           p.Sizing,
+		Scoring:          p.Scoring,
+		IVHistory:        p.IVHistory,
 	}
+}
 
-	// Get current stock price
-	currentPrice, err := s.client.GetLastPrice(conID)
-	if err != nil {
-		return nil, fmt.Errorf("getting price: %w", err)
+// printSymbolScan prints the per-stock progress block for a batch scan
+func printSymbolScan(result *SymbolScan) {
+	fmt.Printf("   Price: $%.2f\n", result.Price)
+	if result.ATMIV > 0 {
+		fmt.Printf("   ATM IV: %.0f%%, IV rank %s\n", result.ATMIV*100, result.IV.FormatRank())
 	}
-
-	fmt.Printf("   Price: $%.2f\n", currentPrice)
-
-	// Get next N Friday expiries
-	targetExpiries := getNextFridayExpiries(months, params.NumExpiries)
-	if len(targetExpiries) == 0 {
-		return nil, fmt.Errorf("no valid expiries found")
+	if result.HV != nil {
+		fmt.Printf("   HV (close/Parkinson): %s\n", FormatHV(result.HV))
 	}
-
-	fmt.Printf("   Expiries: %s\n", formatExpiries(targetExpiries))
-
-	var allContracts []OptionContract
-
-	// Scan each expiry
-	for _, month := range targetExpiries {
-		// Get strikes
-		strikes, err := s.client.GetStrikes(conID, month, currentPrice, params.StrikeRange)
-		if err != nil {
-			fmt.Printf("   ⚠️  Skipping %s: %v\n", month, err)
-			continue
-		}
-
-		expiryContracts := 0
-
-		// Process each strike
-		for _, strike := range strikes {
-			strikeStr := fmt.Sprintf("%.2f", strike)
-
-			// Get contract info
-			contracts, err := s.client.GetContractInfo(conID, month, strikeStr, params.Right)
-			if err != nil {
-				continue
-			}
-
-			time.Sleep(20 * time.Millisecond) // 50 req/s rate limit (60 max)
-
-			// Process each contract
-			for _, contract := range contracts {
-				dte := CalculateDaysToExpiry(contract.MaturityDate)
-
-				// Get pricing
-				pricing, err := s.client.GetOptionPricing(contract.ConID)
-				if err != nil {
-					continue
-				}
-
-				time.Sleep(20 * time.Millisecond) // 50 req/s rate limit (60 max)
-
-				// Skip if no valid bid or ask
-				if pricing.Bid <= 0 && pricing.Ask <= 0 {
-					continue
-				}
-
-				// Calculate mid price
-				midPrice := pricing.Bid
-				if pricing.Ask > 0 {
-					if pricing.Bid > 0 {
-						midPrice = (pricing.Bid + pricing.Ask) / 2
-					} else {
-						midPrice = pricing.Ask
-					}
-				}
-
-				// Calculate intrinsic and extrinsic value
-				var intrinsicValue float64
-				var isITM bool
-
-				if params.Right == "P" {
-					intrinsicValue = math.Max(0, strike-currentPrice)
-					isITM = strike > currentPrice
-				} else {
-					intrinsicValue = math.Max(0, currentPrice-strike)
-					isITM = currentPrice > strike
-				}
-
-				extrinsicValue := math.Max(0, midPrice-intrinsicValue)
-
-				// Calculate metrics
-				premiumPercent := (extrinsicValue / strike) * 100
-				annualizedReturn := (premiumPercent / float64(dte)) * 365
-
-				// Filter by minimum return
-				if annualizedReturn < params.MinReturn {
-					continue
-				}
-
-				totalPremium := midPrice * 100
-				totalExtrinsic := extrinsicValue * 100
-				totalIntrinsic := intrinsicValue * 100
-
-				// Calculate POP and Efficiency
-				pop := (1 - math.Abs(pricing.Delta)) * 100
-				efficiency := 0.0
-				if pop < 100 {
-					efficiency = annualizedReturn / (1 - (pop / 100))
-				}
-
-				// Build contract
-				optContract := OptionContract{
-					Symbol:           stock.Symbol,
-					Strike:           strike,
-					Right:            params.Right,
-					MaturityDate:     contract.MaturityDate,
-					ConID:            contract.ConID,
-					UnderlyingConID:  conID,
-					Bid:              pricing.Bid,
-					Ask:              pricing.Ask,
-					MidPrice:         midPrice,
-					UnderlyingPrice:  currentPrice,
-					Delta:            pricing.Delta,
-					Gamma:            pricing.Gamma,
-					Theta:            pricing.Theta,
-					Vega:             pricing.Vega,
-					ImpliedVol:       pricing.ImpliedVol,
-					DTE:              dte,
-					Premium:          totalPremium,
-					IntrinsicValue:   totalIntrinsic,
-					ExtrinsicValue:   totalExtrinsic,
-					PremiumPercent:   premiumPercent,
-					AnnualizedReturn: annualizedReturn,
-					CapitalRequired:  strike * 100,
-					POP:              pop,
-					Efficiency:       efficiency,
-					IsITM:            isITM,
-				}
-
-				allContracts = append(allContracts, optContract)
-				expiryContracts++
-
-				// Progress feedback
-				itmStr := "OTM"
-				if isITM {
-					itmStr = "ITM"
-				}
-				fmt.Printf("      $%.2f (%s, %dd): $%.0f → %.0f%% ann\n",
-					strike, itmStr, dte, totalExtrinsic, annualizedReturn)
-			}
-		}
-
-		if expiryContracts > 0 {
-			fmt.Printf("   📅 %s: %d contracts\n", month, expiryContracts)
-		}
+	fmt.Printf("   Expiries: %s\n", formatExpiries(result.Expiries))
+	if len(result.ExpectedMoves) > 0 {
+		fmt.Printf("   Expected move: %s\n", FormatExpiryMoves(result.ExpectedMoves))
 	}
-
-	return allContracts, nil
-}
-
-// getNextFridayExpiries returns the next N Friday expiries from available months
-func getNextFridayExpiries(months []string, count int) []string {
-	type expiryDate struct {
-		month string
-		date  time.Time
+	if len(result.Surface.Smiles) > 0 {
+		fmt.Printf("   25Δ put skew: %s\n", FormatSkew(result.Surface))
 	}
 
-	now := time.Now()
-	var expiries []expiryDate
-
-	for _, month := range months {
-		monthDate, err := parseMonthString(month)
-		if err != nil {
-			continue
+	for _, c := range result.Contracts {
+		itmStr := "OTM"
+		if c.IsITM {
+			itmStr = "ITM"
 		}
-
-		expiry := getThirdFriday(monthDate)
-
-		// Only include future expiries
-		if expiry.After(now) {
-			expiries = append(expiries, expiryDate{
-				month: month,
-				date:  expiry,
-			})
+		earnings := ""
+		if c.EarningsDate != "" {
+			earnings = fmt.Sprintf(" ⚠️  earnings %s", c.EarningsDate)
+		}
+		score := ""
+		if c.Score > 0 {
+			score = fmt.Sprintf(", score %.0f (%s)", c.Score, c.ScoreBreakdown)
 		}
+		breakeven := ""
+		if c.ExpectedMove > 0 {
+			breakeven = fmt.Sprintf(", BE $%.2f (%.1f moves)", c.Breakeven, c.BreakevenMoves)
+		}
+		fmt.Printf("      $%.2f (%s, %dd): $%.0f → %.0f%% ann (%.0f%% net)%s, spread %.0f%%, OI %d%s%s\n",
+			c.Strike, itmStr, c.DTE, c.ExtrinsicValue, c.AnnualizedReturn, c.NetAnnualizedReturn, breakeven, c.SpreadPercent, c.OpenInterest, score, earnings)
 	}
 
-	// Sort by date ascending
-	sort.Slice(expiries, func(i, j int) bool {
-		return expiries[i].date.Before(expiries[j].date)
-	})
-
-	// Take first N
-	result := []string{}
-	for i := 0; i < count && i < len(expiries); i++ {
-		result = append(result, expiries[i].month)
+	for _, month := range result.Expiries {
+		if count := result.ExpiryCounts[month]; count > 0 {
+			fmt.Printf("   📅 %s: %d contracts\n", month, count)
+		}
 	}
-
-	return result
 }
 
 // formatExpiries formats expiry months for display
@@ -601,73 +401,3 @@ func loadSolarSystem(filepath string) ([]SolarSystemStock, error) {
 
 	return stocks, nil
 }
-
-// initializeCSV creates the CSV file with header row
-func initializeCSV(filepath string) error {
-	file, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	header := []string{
-		"Symbol", "Strike", "Right", "MaturityDate", "DTE",
-		"Premium", "IntrinsicValue", "ExtrinsicValue",
-		"PremiumPercent", "AnnualizedReturn", "POP", "Efficiency",
-		"ITM", "Delta", "Gamma", "Theta", "Vega", "ImpliedVol",
-		"Bid", "Ask", "MidPrice", "UnderlyingPrice",
-		"CapitalRequired", "ConID", "UnderlyingConID",
-	}
-
-	return writer.Write(header)
-}
-
-// appendContractToCSV appends one contract to the CSV file
-func appendContractToCSV(contract OptionContract, filepath string) error {
-	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	itmStr := "false"
-	if contract.IsITM {
-		itmStr = "true"
-	}
-
-	row := []string{
-		contract.Symbol,
-		fmt.Sprintf("%.2f", contract.Strike),
-		contract.Right,
-		contract.MaturityDate,
-		fmt.Sprintf("%d", contract.DTE),
-		fmt.Sprintf("%.2f", contract.Premium),
-		fmt.Sprintf("%.2f", contract.IntrinsicValue),
-		fmt.Sprintf("%.2f", contract.ExtrinsicValue),
-		fmt.Sprintf("%.2f", contract.PremiumPercent),
-		fmt.Sprintf("%.2f", contract.AnnualizedReturn),
-		fmt.Sprintf("%.2f", contract.POP),
-		fmt.Sprintf("%.2f", contract.Efficiency),
-		itmStr,
-		fmt.Sprintf("%.4f", contract.Delta),
-		fmt.Sprintf("%.4f", contract.Gamma),
-		fmt.Sprintf("%.4f", contract.Theta),
-		fmt.Sprintf("%.4f", contract.Vega),
-		fmt.Sprintf("%.4f", contract.ImpliedVol),
-		fmt.Sprintf("%.2f", contract.Bid),
-		fmt.Sprintf("%.2f", contract.Ask),
-		fmt.Sprintf("%.2f", contract.MidPrice),
-		fmt.Sprintf("%.2f", contract.UnderlyingPrice),
-		fmt.Sprintf("%.2f", contract.CapitalRequired),
-		fmt.Sprintf("%d", contract.ConID),
-		fmt.Sprintf("%d", contract.UnderlyingConID),
-	}
-
-	return writer.Write(row)
-}
@@ -0,0 +1,301 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// Score components; each is scored 0-100, higher is better
+const (
+	ScoreReturn          = "return"          // Net annualized return (200% or more scores 100)
+	ScorePOP             = "pop"             // Probability of profit
+	ScoreLiquidity       = "liquidity"       // Tight spread and deep open interest
+	ScoreIVRank          = "iv-rank"         // IV rank of the underlying (50 without enough IV history)
+	ScoreIVHV            = "iv-hv"           // IV over 20-day historical volatility (1.0 scores 50, 1.5 or more 100; 50 without price history)
+	ScoreOTM             = "otm"             // Distance out of the money (15% or more scores 100)
+	ScoreEarnings        = "earnings"        // Days between expiry and the next earnings (30 or more scores 100; 50 with no earnings date in the calendar)
+	ScoreDiversification = "diversification" // Sector room left under the sector limit after the trade
+)
+
+// ScoreComponents lists the components in display order
+var ScoreComponents = []string{ScoreReturn, ScorePOP, ScoreLiquidity, ScoreIVRank, ScoreIVHV, ScoreOTM, ScoreEarnings, ScoreDiversification}
+
+// Component scales: the value that scores 100
+const (
+	scoreFullReturn       = 200.0 // Net annualized %
+	scoreFullOTM          = 15.0  // % of the stock price
+	scoreFullEarnings     = 30.0  // Days from expiry to earnings
+	scoreFullOpenInterest = 1000  // Contracts
+	scoreFullIVHV         = 1.5   // IV / HV; half that (options priced below realized volatility) scores 0
+	scoreNeutral          = 50.0  // Components without data
+)
+
+// ScoreWeights maps score components to their relative weights
+type ScoreWeights map[string]float64
+
+// DefaultScorePreset is the preset used when none is given
+const DefaultScorePreset = "balanced"
+
+// ScorePresets are the named weightings
+var ScorePresets = map[string]ScoreWeights{
+	"balanced": {
+		ScoreReturn: 3, ScorePOP: 3, ScoreLiquidity: 2, ScoreIVRank: 1, ScoreIVHV: 1, ScoreOTM: 1, ScoreEarnings: 1, ScoreDiversification: 1,
+	},
+	"income": {
+		ScoreReturn: 6, ScorePOP: 2, ScoreLiquidity: 1, ScoreIVRank: 1, ScoreIVHV: 1,
+	},
+	"conservative": {
+		ScoreReturn: 1, ScorePOP: 4, ScoreLiquidity: 1, ScoreOTM: 3, ScoreEarnings: 2, ScoreDiversification: 1,
+	},
+	"liquid": {
+		ScoreReturn: 2, ScorePOP: 2, ScoreLiquidity: 5,
+	},
+	"diversify": {
+		ScoreReturn: 2, ScorePOP: 2, ScoreLiquidity: 1, ScoreDiversification: 4,
+	},
+}
+
+// ParseScoreWeights returns a preset's weights with overrides applied.
+// Overrides are comma-separated component=weight pairs, e.g. "return=4,liquidity=0".
+func ParseScoreWeights(preset, overrides string) (ScoreWeights, error) {
+	if preset == "" {
+		preset = DefaultScorePreset
+	}
+	base, ok := ScorePresets[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown score preset %q (want %s)", preset, strings.Join(ScorePresetNames(), ", "))
+	}
+
+	weights := make(ScoreWeights)
+	for component, weight := range base {
+		weights[component] = weight
+	}
+
+	for _, pair := range strings.Split(overrides, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || !isScoreComponent(parts[0]) {
+			return nil, fmt.Errorf("invalid weight %q (want component=weight with component one of %s)", pair, strings.Join(ScoreComponents, ", "))
+		}
+		weight, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q: must be a number ≥ 0", pair)
+		}
+		weights[parts[0]] = weight
+	}
+
+	return weights, nil
+}
+
+// ScorePresetNames returns the preset names sorted
+func ScorePresetNames() []string {
+	var names []string
+	for name := range ScorePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// String formats weights as component=weight pairs in display order, skipping zero weights
+func (w ScoreWeights) String() string {
+	var parts []string
+	for _, component := range ScoreComponents {
+		if w[component] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%g", component, w[component]))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func isScoreComponent(name string) bool {
+	for _, component := range ScoreComponents {
+		if component == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ScoreBreakdown holds the 0-100 value of every score component
+type ScoreBreakdown struct {
+	Return          float64
+	POP             float64
+	Liquidity       float64
+	IVRank          float64
+	IVHV            float64
+	OTM             float64
+	Earnings        float64
+	Diversification float64
+}
+
+// Value returns the value of one component
+func (b ScoreBreakdown) Value(component string) float64 {
+	switch component {
+	case ScoreReturn:
+		return b.Return
+	case ScorePOP:
+		return b.POP
+	case ScoreLiquidity:
+		return b.Liquidity
+	case ScoreIVRank:
+		return b.IVRank
+	case ScoreIVHV:
+		return b.IVHV
+	case ScoreOTM:
+		return b.OTM
+	case ScoreEarnings:
+		return b.Earnings
+	case ScoreDiversification:
+		return b.Diversification
+	default:
+		return 0
+	}
+}
+
+// String formats the breakdown compactly for tables, e.g. "R80 P92 L65 I50 V60 O40 E100 D70"
+func (b ScoreBreakdown) String() string {
+	return fmt.Sprintf("R%.0f P%.0f L%.0f I%.0f V%.0f O%.0f E%.0f D%.0f",
+		b.Return, b.POP, b.Liquidity, b.IVRank, b.IVHV, b.OTM, b.Earnings, b.Diversification)
+}
+
+// ScoreContext is the market and portfolio data components need beyond the contract itself
+type ScoreContext struct {
+	Events []web.Event // Event calendar for the earnings component
+
+	// Diversification (missing portfolio = neutral)
+	NetWorth         float64
+	Sectors          map[string]string  // Symbol -> sector
+	SectorCapital    map[string]float64 // Sector -> capital deployed
+	MaxSectorPercent float64            // Sector limit as % of net worth
+}
+
+// NewScoreContext builds the context from the portfolio (may be nil) and the sector rule
+func NewScoreContext(portfolio *PortfolioSnapshot, rules web.TradingRules, events []web.Event) ScoreContext {
+	context := ScoreContext{
+		Events:           events,
+		MaxSectorPercent: 100,
+	}
+	if rules.Enabled(web.RuleMaxSector) {
+		context.MaxSectorPercent = rules.Threshold(web.RuleMaxSector)
+	}
+	if portfolio != nil {
+		context.NetWorth = portfolio.NetWorth
+		context.Sectors = web.LoadSectorMapping("data/universe.csv")
+		context.SectorCapital = getCurrentSectorExposure()
+	}
+	return context
+}
+
+// Scorer ranks contracts by a weighted average of score components
+type Scorer struct {
+	Weights ScoreWeights
+	Context ScoreContext
+}
+
+// Score returns the weighted score (0-100) of a contract and its component breakdown
+func (s *Scorer) Score(contract OptionContract) (float64, ScoreBreakdown) {
+	breakdown := ScoreBreakdown{
+		Return:          clampScore(contract.NetAnnualizedReturn / scoreFullReturn * 100),
+		POP:             clampScore(contract.POP),
+		Liquidity:       liquidityScore(contract),
+		IVRank:          scoreNeutral,
+		IVHV:            scoreNeutral,
+		OTM:             otmScore(contract),
+		Earnings:        s.earningsScore(contract),
+		Diversification: s.diversificationScore(contract),
+	}
+	if contract.IVRankWindow > 0 {
+		breakdown.IVRank = clampScore(contract.IVRank)
+	}
+	if contract.IVHVRatio > 0 {
+		breakdown.IVHV = clampScore((contract.IVHVRatio - scoreFullIVHV/2) / (scoreFullIVHV / 2) * 100)
+	}
+
+	total, weights := 0.0, 0.0
+	for _, component := range ScoreComponents {
+		weight := s.Weights[component]
+		total += weight * breakdown.Value(component)
+		weights += weight
+	}
+	if weights == 0 {
+		return 0, breakdown
+	}
+	return total / weights, breakdown
+}
+
+// scoreContract sets the score and breakdown of a contract (no-op without a scorer)
+func scoreContract(scorer *Scorer, contract *OptionContract) {
+	if scorer == nil {
+		return
+	}
+	contract.Score, contract.ScoreBreakdown = scorer.Score(*contract)
+}
+
+// liquidityScore averages a spread score (0% = 100, 50% or wider = 0) and an open interest score
+// (log scale, 1000 contracts = 100)
+func liquidityScore(contract OptionContract) float64 {
+	spread := clampScore(100 - contract.SpreadPercent*2)
+	openInterest := clampScore(math.Log10(float64(contract.OpenInterest)+1) / math.Log10(scoreFullOpenInterest) * 100)
+	return (spread + openInterest) / 2
+}
+
+// otmScore scores the distance between the strike and the stock price; in the money scores 0
+func otmScore(contract OptionContract) float64 {
+	if contract.UnderlyingPrice <= 0 {
+		return 0
+	}
+	distance := contract.UnderlyingPrice - contract.Strike
+	if contract.Right == "C" {
+		distance = -distance
+	}
+	return clampScore(distance / contract.UnderlyingPrice * 100 / scoreFullOTM * 100)
+}
+
+// earningsScore scores the buffer between expiry and the next earnings; earnings before expiry score 0,
+// and a symbol without an upcoming earnings date in the calendar scores neutral. The contract's DTE
+// counts from when it was quoted, so the calendar is searched from then.
+func (s *Scorer) earningsScore(contract OptionContract) float64 {
+	if contract.EarningsDate != "" {
+		return 0
+	}
+	expiry, err := time.Parse("20060102", contract.MaturityDate)
+	if err != nil {
+		return scoreNeutral
+	}
+	quoted := expiry.AddDate(0, 0, -contract.DTE)
+	event, ok := web.NextEarnings(s.Context.Events, contract.Symbol, quoted, quoted.AddDate(1, 0, 0))
+	if !ok {
+		return scoreNeutral
+	}
+	date, err := time.Parse("2006-01-02", event.Date)
+	if err != nil {
+		return scoreNeutral
+	}
+	buffer := date.Sub(expiry).Hours() / 24
+	return clampScore(buffer / scoreFullEarnings * 100)
+}
+
+// diversificationScore scores the share of the sector limit left after securing the contract
+func (s *Scorer) diversificationScore(contract OptionContract) float64 {
+	sector := s.Context.Sectors[contract.Symbol]
+	if s.Context.NetWorth <= 0 || sector == "" || s.Context.MaxSectorPercent <= 0 {
+		return scoreNeutral
+	}
+	after := (s.Context.SectorCapital[sector] + contract.CapitalRequired) / s.Context.NetWorth * 100
+	return clampScore((1 - after/s.Context.MaxSectorPercent) * 100)
+}
+
+// clampScore limits a component value to 0-100
+func clampScore(value float64) float64 {
+	return math.Max(0, math.Min(100, value))
+}
@@ -0,0 +1,432 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"mnmlsm/web"
+)
+
+// PipelineConfig configures the universe → elimination → scan → shortlist pipeline
+type PipelineConfig struct {
+	UpdateUniverse bool   `json:"updateUniverse"` // Refresh universe.csv prices before elimination
+	Exchange       string `json:"exchange"`
+	Workers        int    `json:"workers"`
+
+	// Scan (see BatchScanParams)
+	Right            string  `json:"right"`
+	MinReturn        float64 `json:"minReturn"` // 0 = the min-return rule threshold
+	StrikeMode       string  `json:"strikeMode"`
+	StrikeRange      float64 `json:"strikeRange"`
+	MinDelta         float64 `json:"minDelta"`
+	MaxDelta         float64 `json:"maxDelta"`
+	Expiries         int     `json:"expiries"`
+	MaxDTE           int     `json:"maxDte"`
+	MinOpenInterest  int     `json:"minOpenInterest"`
+	MinVolume        int     `json:"minVolume"`
+	MaxSpreadPercent float64 `json:"maxSpreadPercent"`
+	FillMode         string  `json:"fill"`
+	FillFraction     float64 `json:"fillFraction"`
+	EarningsMode     string  `json:"earnings"`
+	SortBy           string  `json:"sort"`
+	Explain          bool    `json:"explain"` // Print each symbol's scan funnel and rejections
+
+	// Ranking score (see Scorer)
+	ScorePreset  string       `json:"score"`
+	ScoreWeights ScoreWeights `json:"weights"` // Overrides of the preset's weights
+
+	// Position sizing (see SizingParams)
+	RiskModel       string  `json:"riskModel"`
+	RiskSigmas      float64 `json:"riskSigmas"`
+	RiskStopPercent float64 `json:"riskStopPercent"`
+
+	// Shortlist
+	ShortlistSize int `json:"shortlistSize"` // Contracts in the shortlist (0 = all)
+	PerSymbol     int `json:"perSymbol"`     // Contracts per symbol (0 = no limit)
+
+	// Inputs and outputs; universe.csv and solar-system.csv are always data/
+	OptionsChainCSV string `json:"optionsChainCsv"` // All scanned contracts; .json, .ndjson or .db switch format
+	HistoryDir      string `json:"historyDir"`      // Scan run history for scan-diff ("" = not saved)
+	ShortlistCSV    string `json:"shortlistCsv"`
+	EventsCSV       string `json:"eventsCsv"`
+	IVHistoryCSV    string `json:"ivHistoryCsv"`    // ATM IV history for IV rank, recorded to after the scan ("" = neither)
+	VolSurfacesJSON string `json:"volSurfacesJson"` // Smile and term structure of each symbol for the volatility page ("" = not saved)
+	CommissionsJSON string `json:"commissionsJson"`
+}
+
+// DefaultPipelineConfig returns the scan-all defaults with a ten-contract shortlist
+func DefaultPipelineConfig() PipelineConfig {
+	return PipelineConfig{
+		UpdateUniverse: true,
+		Exchange:       "NASDAQ",
+		Workers:        DefaultWorkers,
+
+		Right:            "P",
+		StrikeMode:       StrikeModeDollar,
+		MinDelta:         0.15,
+		MaxDelta:         0.30,
+		Expiries:         2,
+		MaxSpreadPercent: 25,
+		FillMode:         FillMid,
+		FillFraction:     DefaultFillFraction,
+		EarningsMode:     EarningsExclude,
+		SortBy:           "score",
+		ScorePreset:      DefaultScorePreset,
+
+		RiskModel:       RiskSigma,
+		RiskSigmas:      DefaultRiskSigmas,
+		RiskStopPercent: DefaultRiskStopPercent,
+
+		ShortlistSize: 10,
+		PerSymbol:     1,
+
+		OptionsChainCSV: "data/options-chain.csv",
+		HistoryDir:      DefaultScanHistoryDir,
+		ShortlistCSV:    "data/shortlist.csv",
+		EventsCSV:       "data/events.csv",
+		IVHistoryCSV:    DefaultIVHistoryCSV,
+		VolSurfacesJSON: DefaultVolSurfacesJSON,
+		CommissionsJSON: "data/commissions.json",
+	}
+}
+
+// LoadPipelineConfig reads a pipeline config; settings missing from the file keep their defaults.
+// A missing file gives the defaults.
+func LoadPipelineConfig(filename string) (PipelineConfig, error) {
+	config := DefaultPipelineConfig()
+
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	return config, nil
+}
+
+// ShortlistEntry is a ranked contract with the portfolio context of its symbol
+type ShortlistEntry struct {
+	Rank      int
+	Contract  OptionContract
+	Candidate StockCandidate // Elimination context: position %, sector %, existing positions
+
+	Contracts            int     // Recommended contracts after the entries ranked above it
+	Capital              float64 // Cash the recommended contracts secure
+	Premium              float64 // Net premium of the recommended contracts
+	PositionPercentAfter float64 // Symbol capital as % of net worth after the trade
+	SectorPercentAfter   float64 // Sector capital as % of net worth after the trade
+}
+
+// PipelineResult contains the output of every pipeline stage
+type PipelineResult struct {
+	Universe    *UniverseUpdate // nil when prices weren't refreshed
+	Elimination *EliminationResult
+	Contracts   []OptionContract // Every contract that passed the scan filters, ranked
+	Shortlist   []ShortlistEntry
+	Capital     float64 // Cash the whole shortlist secures
+	Premium     float64 // Net premium of the whole shortlist
+}
+
+// RunPipeline refreshes universe prices, runs elimination, scans the survivors and ranks the
+// contracts into a shortlist sized against the portfolio. It writes universe.csv,
+// solar-system.csv, the options chain and the shortlist along the way.
+func RunPipeline(provider MarketDataProvider, config PipelineConfig) (*PipelineResult, error) {
+	result := &PipelineResult{}
+
+	// 1. Universe prices, never from a replayed chain, whose prices are stale
+	if config.UpdateUniverse && isReplay(provider) {
+		fmt.Println("⏭️  Not updating universe.csv prices from a snapshot")
+		fmt.Println()
+	} else if config.UpdateUniverse {
+		fmt.Println("🔄 Updating universe.csv prices...")
+		update, err := UpdateUniversePrices(provider, config.Exchange, config.Workers)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("   %d updated, %d failed (previous price kept)\n\n", update.Updated, len(update.Failed))
+		result.Universe = update
+	}
+
+	// 2. Elimination
+	fmt.Println("🪐 Running elimination filters...")
+	elimination, err := RunElimination()
+	if err != nil {
+		return nil, fmt.Errorf("elimination: %w", err)
+	}
+	fmt.Printf("   %d survivors, %d eliminated\n\n", len(elimination.Survivors), len(elimination.Eliminated))
+	result.Elimination = elimination
+
+	if len(elimination.Survivors) == 0 {
+		return result, nil
+	}
+
+	// 3. Scan the survivors, sized and scored against the same portfolio elimination used
+	portfolio, err := LoadPortfolioSnapshot(elimination.Rules)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := config.scoreWeights()
+	if err != nil {
+		return nil, err
+	}
+	params := config.batchScanParams(elimination.Rules, portfolio)
+	params.Scoring = &Scorer{
+		Weights: weights,
+		Context: NewScoreContext(portfolio, elimination.Rules, params.Events),
+	}
+
+	contracts, err := NewScanner(provider).scanAll(params)
+	if err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	fmt.Println()
+	SortContracts(contracts, config.SortBy)
+	result.Contracts = contracts
+
+	// 4. Shortlist
+	result.Shortlist = buildShortlist(contracts, elimination, portfolio, config)
+	for _, entry := range result.Shortlist {
+		result.Capital += entry.Capital
+		result.Premium += entry.Premium
+	}
+
+	if config.ShortlistCSV != "" {
+		if err := writeShortlistCSV(result.Shortlist, config.ShortlistCSV); err != nil {
+			return nil, fmt.Errorf("failed to write shortlist: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// batchScanParams builds the scan parameters for the elimination survivors
+func (c PipelineConfig) batchScanParams(rules web.TradingRules, portfolio *PortfolioSnapshot) BatchScanParams {
+	minReturn := c.MinReturn
+	if minReturn <= 0 && rules.Enabled(web.RuleMinReturn) {
+		minReturn = rules.Threshold(web.RuleMinReturn)
+	}
+
+	params := BatchScanParams{
+		SolarSystemCSV: "data/solar-system.csv", // Written by RunElimination
+		Output:         c.OptionsChainCSV,
+		Exchange:       c.Exchange,
+		Right:          c.Right,
+		MinReturn:      minReturn,
+		StrikeMode:     c.StrikeMode,
+		StrikeRange:    c.StrikeRange,
+		MinDelta:       c.MinDelta,
+		MaxDelta:       c.MaxDelta,
+		NumExpiries:    c.Expiries,
+		MaxDTE:         c.MaxDTE,
+		Workers:        c.Workers,
+		SortBy:         c.SortBy,
+		HistoryDir:     c.HistoryDir,
+		Explain:        c.Explain,
+
+		MinOpenInterest:  c.MinOpenInterest,
+		MinVolume:        c.MinVolume,
+		MaxSpreadPercent: c.MaxSpreadPercent,
+		FillMode:         c.FillMode,
+		FillFraction:     c.FillFraction,
+
+		Events:       web.LoadEvents(c.EventsCSV),
+		EarningsMode: c.EarningsMode,
+	}
+	if c.IVHistoryCSV != "" {
+		params.IVHistory = web.LoadIVHistory(c.IVHistoryCSV)
+		params.IVHistoryCSV = c.IVHistoryCSV
+	}
+	params.VolSurfacesJSON = c.VolSurfacesJSON
+	if params.Output == "" {
+		params.Output = "data/options-chain.csv"
+	}
+
+	commissions := web.LoadCommissionSchedule(c.CommissionsJSON)
+	params.Commissions = &commissions
+
+	sizing := NewSizingParams(portfolio, rules, c.RiskModel)
+	sizing.Sigmas = c.RiskSigmas
+	sizing.StopPercent = c.RiskStopPercent
+	params.Sizing = &sizing
+
+	return params
+}
+
+// scoreWeights returns the preset's weights with the config's overrides applied
+func (c PipelineConfig) scoreWeights() (ScoreWeights, error) {
+	weights, err := ParseScoreWeights(c.ScorePreset, "")
+	if err != nil {
+		return nil, err
+	}
+	for component, weight := range c.ScoreWeights {
+		if !isScoreComponent(component) || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %s=%g (want one of %s, ≥ 0)", component, weight, strings.Join(ScoreComponents, ", "))
+		}
+		weights[component] = weight
+	}
+	return weights, nil
+}
+
+// buildShortlist walks the ranked contracts and sizes each one against the cash, position and
+// sector room left by the entries above it, so the whole shortlist can be opened together
+func buildShortlist(contracts []OptionContract, elimination *EliminationResult, portfolio *PortfolioSnapshot, config PipelineConfig) []ShortlistEntry {
+	candidates := make(map[string]StockCandidate)
+	for _, c := range elimination.Survivors {
+		candidates[c.Symbol] = c
+	}
+
+	rules := elimination.Rules
+	netWorth := portfolio.NetWorth
+	deployable := portfolio.Deployable
+	symbolCapital := make(map[string]float64) // Capital added per symbol by the shortlist
+	sectorCapital := make(map[string]float64) // Capital added per sector by the shortlist
+	perSymbol := make(map[string]int)
+
+	var shortlist []ShortlistEntry
+	for _, contract := range contracts {
+		if config.ShortlistSize > 0 && len(shortlist) >= config.ShortlistSize {
+			break
+		}
+		if config.PerSymbol > 0 && perSymbol[contract.Symbol] >= config.PerSymbol {
+			continue
+		}
+		candidate, ok := candidates[contract.Symbol]
+		if !ok || contract.CapitalRequired <= 0 || contract.RecommendedContracts < 1 || netWorth <= 0 {
+			continue
+		}
+
+		existing := candidate.ExistingCapital + symbolCapital[contract.Symbol]
+		sectorCurrent := candidate.SectorExposure - candidate.PositionCost + sectorCapital[candidate.Sector]
+
+		count := contract.RecommendedContracts
+		count = minLimit(count, maxContracts(deployable, contract.CapitalRequired))
+		if rules.Enabled(web.RuleMaxPosition) {
+			room := netWorth*rules.Threshold(web.RuleMaxPosition)/100 - existing
+			count = minLimit(count, maxContracts(room, contract.CapitalRequired))
+		}
+		if rules.Enabled(web.RuleMaxSector) {
+			room := netWorth*rules.Threshold(web.RuleMaxSector)/100 - sectorCurrent
+			count = minLimit(count, maxContracts(room, contract.CapitalRequired))
+		}
+		if count < 1 {
+			continue
+		}
+
+		capital := contract.CapitalRequired * float64(count)
+		deployable -= capital
+		symbolCapital[contract.Symbol] += capital
+		sectorCapital[candidate.Sector] += capital
+		perSymbol[contract.Symbol]++
+
+		shortlist = append(shortlist, ShortlistEntry{
+			Rank:                 len(shortlist) + 1,
+			Contract:             contract,
+			Candidate:            candidate,
+			Contracts:            count,
+			Capital:              capital,
+			Premium:              contract.NetPremium * float64(count),
+			PositionPercentAfter: (existing + capital) / netWorth * 100,
+			SectorPercentAfter:   (sectorCurrent + capital) / netWorth * 100,
+		})
+	}
+
+	return shortlist
+}
+
+// minLimit returns the smaller of a contract count and a limit
+func minLimit(count, limit int) int {
+	if limit < count {
+		return limit
+	}
+	return count
+}
+
+// writeShortlistCSV writes the shortlist with the portfolio context of each symbol
+func writeShortlistCSV(shortlist []ShortlistEntry, filepath string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{
+		"Rank", "Symbol", "Sector", "Strike", "Right", "MaturityDate", "DTE",
+		"UnderlyingPrice", "FillPrice", "NetPremium", "AnnualizedReturn", "NetAnnualizedReturn", "POP", "Efficiency", "NetEfficiency", "Breakeven", "BreakevenMoves",
+		"Delta", "ImpliedVol", "IVHVRatio", "SpreadPercent", "OpenInterest", "EarningsDate",
+		"CapitalRequired", "RiskPerContract", "RecommendedContracts", "Capital", "TotalNetPremium",
+		"ExistingStockPosition", "ExistingPutPosition", "ExistingCapital",
+		"PositionSizePercent", "SectorPercent", "PositionPercentAfter", "SectorPercentAfter",
+		"Score", "ScoreReturn", "ScorePOP", "ScoreLiquidity", "ScoreIVRank", "ScoreIVHV", "ScoreOTM", "ScoreEarnings", "ScoreDiversification",
+		"ConID",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, e := range shortlist {
+		c := e.Contract
+		row := []string{
+			fmt.Sprintf("%d", e.Rank),
+			c.Symbol,
+			e.Candidate.Sector,
+			fmt.Sprintf("%.2f", c.Strike),
+			c.Right,
+			c.MaturityDate,
+			fmt.Sprintf("%d", c.DTE),
+			fmt.Sprintf("%.2f", c.UnderlyingPrice),
+			fmt.Sprintf("%.2f", c.FillPrice),
+			fmt.Sprintf("%.2f", c.NetPremium),
+			fmt.Sprintf("%.2f", c.AnnualizedReturn),
+			fmt.Sprintf("%.2f", c.NetAnnualizedReturn),
+			fmt.Sprintf("%.2f", c.POP),
+			fmt.Sprintf("%.2f", c.Efficiency),
+			fmt.Sprintf("%.2f", c.NetEfficiency),
+			fmt.Sprintf("%.2f", c.Breakeven),
+			fmt.Sprintf("%.2f", c.BreakevenMoves),
+			fmt.Sprintf("%.4f", c.Delta),
+			fmt.Sprintf("%.4f", c.ImpliedVol),
+			fmt.Sprintf("%.2f", c.IVHVRatio),
+			fmt.Sprintf("%.1f", c.SpreadPercent),
+			fmt.Sprintf("%d", c.OpenInterest),
+			c.EarningsDate,
+			fmt.Sprintf("%.2f", c.CapitalRequired),
+			fmt.Sprintf("%.2f", c.RiskPerContract),
+			fmt.Sprintf("%d", e.Contracts),
+			fmt.Sprintf("%.2f", e.Capital),
+			fmt.Sprintf("%.2f", e.Premium),
+			fmt.Sprintf("%t", e.Candidate.ExistingStockPosition),
+			fmt.Sprintf("%t", e.Candidate.ExistingPutPosition),
+			fmt.Sprintf("%.2f", e.Candidate.ExistingCapital),
+			fmt.Sprintf("%.2f", e.Candidate.PositionSizePercent),
+			fmt.Sprintf("%.2f", e.Candidate.SectorPercent),
+			fmt.Sprintf("%.2f", e.PositionPercentAfter),
+			fmt.Sprintf("%.2f", e.SectorPercentAfter),
+			fmt.Sprintf("%.1f", c.Score),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.Return),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.POP),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.Liquidity),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.IVRank),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.IVHV),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.OTM),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.Earnings),
+			fmt.Sprintf("%.1f", c.ScoreBreakdown.Diversification),
+			fmt.Sprintf("%d", c.ConID),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
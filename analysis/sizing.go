@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"math"
+
+	"mnmlsm/web"
+)
+
+// Risk definitions for SizingParams.RiskModel (rule 8: maximum 2% risk per trade)
+const (
+	RiskSigma = "sigma" // Loss at expiry after a Sigmas standard-deviation move against us (default)
+	RiskStop  = "stop"  // Loss at expiry if the stock moves StopPercent against us
+	RiskMax   = "max"   // Loss if the stock goes to zero: the capital required less premium
+)
+
+// Risk model defaults
+const (
+	DefaultRiskSigmas      = 2.0
+	DefaultRiskStopPercent = 20.0
+)
+
+// SizingParams defines how many contracts a scan candidate may be sized to
+type SizingParams struct {
+	Portfolio          *PortfolioSnapshot
+	RiskPercent        float64 // Max loss per trade as % of net worth (0 = no risk limit)
+	MaxPositionPercent float64 // Max capital per symbol as % of net worth (0 = no position limit)
+	RiskModel          string  // "sigma" (default), "stop" or "max"
+	Sigmas             float64 // Move size in sigma mode (0 = DefaultRiskSigmas)
+	StopPercent        float64 // Move size in stop mode, % of the stock price (0 = DefaultRiskStopPercent)
+}
+
+// NewSizingParams takes the risk-per-trade and position limits from the enabled rules
+func NewSizingParams(portfolio *PortfolioSnapshot, rules web.TradingRules, riskModel string) SizingParams {
+	params := SizingParams{
+		Portfolio: portfolio,
+		RiskModel: riskModel,
+	}
+	if rules.Enabled(web.RuleMaxRisk) {
+		params.RiskPercent = rules.Threshold(web.RuleMaxRisk)
+	}
+	if rules.Enabled(web.RuleMaxPosition) {
+		params.MaxPositionPercent = rules.Threshold(web.RuleMaxPosition)
+	}
+	return params
+}
+
+// RiskPerContract returns the dollar loss of one contract under the risk model, net of premium
+func (p SizingParams) RiskPerContract(contract OptionContract) float64 {
+	premium := contract.Premium - contract.Commission
+
+	var move float64
+	switch p.RiskModel {
+	case RiskMax:
+		return contract.CapitalRequired - premium
+	case RiskStop:
+		stop := p.StopPercent
+		if stop <= 0 {
+			stop = DefaultRiskStopPercent
+		}
+		move = contract.UnderlyingPrice * stop / 100
+	default: // RiskSigma
+		sigmas := p.Sigmas
+		if sigmas <= 0 {
+			sigmas = DefaultRiskSigmas
+		}
+		if contract.ImpliedVol <= 0 {
+			// No volatility to size the move with; assume the worst case
+			return contract.CapitalRequired - premium
+		}
+		move = ImpliedMove(contract.UnderlyingPrice, contract.ImpliedVol, contract.DTE) * sigmas
+	}
+
+	// Intrinsic value at expiry after the adverse move
+	if contract.Right == "C" {
+		return math.Max(0, contract.UnderlyingPrice+move-contract.Strike)*100 - premium
+	}
+	return math.Max(0, contract.Strike-math.Max(0, contract.UnderlyingPrice-move))*100 - premium
+}
+
+// sizeContract sets the contract limits of the risk, position and cash rules and the recommended count.
+// A limit of -1 means the rule doesn't bind.
+func sizeContract(params *SizingParams, contract *OptionContract) {
+	if params == nil || params.Portfolio == nil || contract.CapitalRequired <= 0 {
+		return
+	}
+	portfolio := params.Portfolio
+
+	contract.RiskPerContract = params.RiskPerContract(*contract)
+
+	contract.MaxContractsByRisk = -1
+	if params.RiskPercent > 0 && contract.RiskPerContract > 0 {
+		contract.MaxContractsByRisk = maxContracts(portfolio.NetWorth*params.RiskPercent/100, contract.RiskPerContract)
+	}
+
+	contract.MaxContractsByPosition = -1
+	if params.MaxPositionPercent > 0 {
+		room := portfolio.NetWorth*params.MaxPositionPercent/100 - portfolio.ExistingCapital(contract.Symbol)
+		contract.MaxContractsByPosition = maxContracts(room, contract.CapitalRequired)
+	}
+
+	// No margin: every contract is secured by deployable cash
+	contract.MaxContractsByCash = maxContracts(portfolio.Deployable, contract.CapitalRequired)
+
+	contract.RecommendedContracts = contract.MaxContractsByCash
+	for _, limit := range []int{contract.MaxContractsByRisk, contract.MaxContractsByPosition} {
+		if limit >= 0 && limit < contract.RecommendedContracts {
+			contract.RecommendedContracts = limit
+		}
+	}
+}
+
+// maxContracts returns how many whole contracts of a cost fit in a budget
+func maxContracts(budget, perContract float64) int {
+	if budget <= 0 || perContract <= 0 {
+		return 0
+	}
+	return int(math.Floor(budget / perContract))
+}
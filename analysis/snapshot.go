@@ -0,0 +1,304 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mnmlsm/ibkr"
+)
+
+// Snapshot is a saved option chain that can be replayed through a MemoryProvider
+type Snapshot struct {
+	SavedAt     time.Time            `json:"savedAt"`
+	Underlyings []SnapshotUnderlying `json:"underlyings"`
+}
+
+// SnapshotUnderlying is one stock and its saved option contracts
+type SnapshotUnderlying struct {
+	Symbol   string           `json:"symbol"`
+	Exchange string           `json:"exchange"`
+	ConID    int              `json:"conid"`
+	Price    float64          `json:"price"`
+	Months   []string         `json:"months"`
+	Options  []SnapshotOption `json:"options"`
+	Bars     []ibkr.Bar       `json:"bars,omitempty"` // Daily history, oldest first (JSON snapshots only)
+}
+
+// SnapshotOption is one saved option contract with its quote
+type SnapshotOption struct {
+	Month        string  `json:"month"`
+	ConID        int     `json:"conid"`
+	Strike       float64 `json:"strike"`
+	Right        string  `json:"right"`
+	MaturityDate string  `json:"maturityDate"`
+	Bid          float64 `json:"bid"`
+	Ask          float64 `json:"ask"`
+	Last         float64 `json:"last"`
+	Delta        float64 `json:"delta"`
+	Gamma        float64 `json:"gamma"`
+	Theta        float64 `json:"theta"`
+	Vega         float64 `json:"vega"`
+	ImpliedVol   float64 `json:"impliedVol"`
+	OpenInterest int     `json:"openInterest"`
+	Volume       int     `json:"volume"`
+}
+
+// snapshotHeader is the column layout of CSV snapshots (one row per option)
+var snapshotHeader = []string{
+	"Symbol", "Exchange", "UnderlyingConID", "UnderlyingPrice", "Months",
+	"Month", "ConID", "Strike", "Right", "MaturityDate",
+	"Bid", "Ask", "Last", "Delta", "Gamma", "Theta", "Vega", "ImpliedVol",
+	"OpenInterest", "Volume",
+}
+
+// snapshotMinColumns is the column count of snapshots saved before liquidity was recorded
+const snapshotMinColumns = 18
+
+func (o SnapshotOption) contractInfo(u *SnapshotUnderlying) ibkr.ContractInfo {
+	return ibkr.ContractInfo{
+		ConID:           o.ConID,
+		Symbol:          u.Symbol,
+		Strike:          o.Strike,
+		Right:           o.Right,
+		MaturityDate:    o.MaturityDate,
+		Multiplier:      "100",
+		UnderlyingConID: u.ConID,
+	}
+}
+
+func (o SnapshotOption) pricing() ibkr.OptionPricing {
+	return ibkr.OptionPricing{
+		Bid:          o.Bid,
+		Ask:          o.Ask,
+		LastPrice:    o.Last,
+		Delta:        o.Delta,
+		Gamma:        o.Gamma,
+		Theta:        o.Theta,
+		Vega:         o.Vega,
+		ImpliedVol:   o.ImpliedVol,
+		OpenInterest: o.OpenInterest,
+		Volume:       o.Volume,
+	}
+}
+
+func (o *SnapshotOption) setPricing(p ibkr.OptionPricing) {
+	o.Bid = p.Bid
+	o.Ask = p.Ask
+	o.Last = p.LastPrice
+	o.Delta = p.Delta
+	o.Gamma = p.Gamma
+	o.Theta = p.Theta
+	o.Vega = p.Vega
+	o.ImpliedVol = p.ImpliedVol
+	o.OpenInterest = p.OpenInterest
+	o.Volume = p.Volume
+}
+
+// LoadSnapshot reads a saved chain (.json or .csv) into a MemoryProvider
+func LoadSnapshot(path string) (*MemoryProvider, error) {
+	var snapshot Snapshot
+	var err error
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		snapshot, err = readSnapshotCSV(path)
+	} else {
+		snapshot, err = readSnapshotJSON(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading snapshot %s: %w", path, err)
+	}
+
+	// CSV snapshots (and JSON ones saved before SavedAt) don't record when they were taken,
+	// so the file's modification time stands in
+	if snapshot.SavedAt.IsZero() {
+		if info, err := os.Stat(path); err == nil {
+			snapshot.SavedAt = info.ModTime()
+		}
+	}
+
+	provider := NewMemoryProvider()
+	provider.SetAsOf(snapshot.SavedAt)
+	for _, u := range snapshot.Underlyings {
+		provider.SetUnderlying(u.Symbol, u.Exchange, u.ConID, u.Price, u.Months)
+		for _, option := range u.Options {
+			provider.AddOption(u.ConID, option)
+		}
+		if len(u.Bars) > 0 {
+			provider.SetBars(u.ConID, u.Bars)
+		}
+	}
+
+	return provider, nil
+}
+
+// SaveSnapshot writes everything held by a MemoryProvider to path (.json or .csv). A replayed
+// chain keeps the time it was captured.
+func SaveSnapshot(path string, provider *MemoryProvider) error {
+	snapshot := provider.Snapshot()
+	snapshot.SavedAt = provider.AsOf()
+	if snapshot.SavedAt.IsZero() {
+		snapshot.SavedAt = time.Now()
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return writeSnapshotCSV(path, snapshot)
+	}
+	return writeSnapshotJSON(path, snapshot)
+}
+
+func readSnapshotJSON(path string) (Snapshot, error) {
+	var snapshot Snapshot
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+
+	err = json.Unmarshal(data, &snapshot)
+	return snapshot, err
+}
+
+func writeSnapshotJSON(path string, snapshot Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func readSnapshotCSV(path string) (Snapshot, error) {
+	var snapshot Snapshot
+
+	file, err := os.Open(path)
+	if err != nil {
+		return snapshot, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return snapshot, err
+	}
+
+	index := make(map[int]int) // Underlying ConID -> position in snapshot.Underlyings
+	for i, record := range records {
+		if i == 0 || len(record) < snapshotMinColumns {
+			continue // Skip header
+		}
+
+		conID, _ := strconv.Atoi(record[2])
+		pos, ok := index[conID]
+		if !ok {
+			price, _ := strconv.ParseFloat(record[3], 64)
+			var months []string
+			if record[4] != "" {
+				months = strings.Split(record[4], ";")
+			}
+			snapshot.Underlyings = append(snapshot.Underlyings, SnapshotUnderlying{
+				Symbol:   record[0],
+				Exchange: record[1],
+				ConID:    conID,
+				Price:    price,
+				Months:   months,
+			})
+			pos = len(snapshot.Underlyings) - 1
+			index[conID] = pos
+		}
+
+		// Underlyings without any options are saved as a row with empty option columns
+		optionConID, err := strconv.Atoi(record[6])
+		if err != nil {
+			continue
+		}
+
+		strike, _ := strconv.ParseFloat(record[7], 64)
+		option := SnapshotOption{
+			Month:        record[5],
+			ConID:        optionConID,
+			Strike:       strike,
+			Right:        record[8],
+			MaturityDate: record[9],
+		}
+		option.Bid, _ = strconv.ParseFloat(record[10], 64)
+		option.Ask, _ = strconv.ParseFloat(record[11], 64)
+		option.Last, _ = strconv.ParseFloat(record[12], 64)
+		option.Delta, _ = strconv.ParseFloat(record[13], 64)
+		option.Gamma, _ = strconv.ParseFloat(record[14], 64)
+		option.Theta, _ = strconv.ParseFloat(record[15], 64)
+		option.Vega, _ = strconv.ParseFloat(record[16], 64)
+		option.ImpliedVol, _ = strconv.ParseFloat(record[17], 64)
+		if len(record) >= len(snapshotHeader) {
+			option.OpenInterest, _ = strconv.Atoi(record[18])
+			option.Volume, _ = strconv.Atoi(record[19])
+		}
+
+		snapshot.Underlyings[pos].Options = append(snapshot.Underlyings[pos].Options, option)
+	}
+
+	return snapshot, nil
+}
+
+func writeSnapshotCSV(path string, snapshot Snapshot) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	if err := writer.Write(snapshotHeader); err != nil {
+		return err
+	}
+
+	for _, u := range snapshot.Underlyings {
+		underlying := []string{
+			u.Symbol,
+			u.Exchange,
+			strconv.Itoa(u.ConID),
+			fmt.Sprintf("%.2f", u.Price),
+			strings.Join(u.Months, ";"),
+		}
+
+		if len(u.Options) == 0 {
+			row := append(underlying, make([]string, len(snapshotHeader)-len(underlying))...)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, o := range u.Options {
+			row := append(append([]string{}, underlying...),
+				o.Month,
+				strconv.Itoa(o.ConID),
+				fmt.Sprintf("%.2f", o.Strike),
+				o.Right,
+				o.MaturityDate,
+				fmt.Sprintf("%.4f", o.Bid),
+				fmt.Sprintf("%.4f", o.Ask),
+				fmt.Sprintf("%.4f", o.Last),
+				fmt.Sprintf("%.4f", o.Delta),
+				fmt.Sprintf("%.4f", o.Gamma),
+				fmt.Sprintf("%.4f", o.Theta),
+				fmt.Sprintf("%.4f", o.Vega),
+				fmt.Sprintf("%.4f", o.ImpliedVol),
+				strconv.Itoa(o.OpenInterest),
+				strconv.Itoa(o.Volume),
+			)
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+)
+
+// Strike selection modes for ScanParams.StrikeMode
+const (
+	StrikeModeDollar  = "dollar"  // StrikeRange is +/- dollars around the current price
+	StrikeModePercent = "percent" // StrikeRange is +/- a fraction of the current price (0.1 = 10%)
+	StrikeModeOTM     = "otm"     // Out-of-the-money strikes only, within StrikeRange fraction (0 = all)
+	StrikeModeDelta   = "delta"   // Strikes whose |delta| falls within MinDelta..MaxDelta
+)
+
+// defaultStrikeRanges is the band used for each mode when ScanParams.StrikeRange is 0
+var defaultStrikeRanges = map[string]float64{
+	StrikeModeDollar:  5.0,         // +/- $5
+	StrikeModePercent: 0.1,         // +/- 10%
+	StrikeModeOTM:     math.Inf(1), // Every OTM strike
+	StrikeModeDelta:   0.3,         // OTM strikes within 30%, narrowed by delta after pricing
+}
+
+// selectStrikes picks the strikes to scan according to the strike selection mode.
+// Delta mode can only be decided after pricing, so it keeps the OTM strikes in range here.
+func selectStrikes(strikes []float64, currentPrice float64, params ScanParams) []float64 {
+	minStrike, maxStrike, otmOnly := strikeBand(strikes, currentPrice, params)
+
+	filtered := make([]float64, 0)
+	for _, strike := range strikes {
+		if strike < minStrike || strike > maxStrike {
+			continue
+		}
+		// OTM-only modes exclude the at-the-money strike itself
+		if otmOnly && strike == currentPrice {
+			continue
+		}
+		filtered = append(filtered, strike)
+	}
+	return filtered
+}
+
+// strikeRejection returns why selectStrikes drops a strike
+func strikeRejection(strike float64, strikes []float64, currentPrice float64, params ScanParams) (reason, detail string) {
+	minStrike, maxStrike, otmOnly := strikeBand(strikes, currentPrice, params)
+	if otmOnly {
+		itm := (params.Right == "P" && strike > currentPrice) || (params.Right != "P" && strike < currentPrice)
+		switch {
+		case strike == currentPrice:
+			return "at the money", fmt.Sprintf("%s mode is OTM only", params.StrikeMode)
+		case itm:
+			return "in the money", fmt.Sprintf("%s mode is OTM only", params.StrikeMode)
+		}
+	}
+
+	switch {
+	case math.IsInf(minStrike, -1):
+		return "outside strike range", fmt.Sprintf("below $%.2f", maxStrike)
+	case math.IsInf(maxStrike, 1):
+		return "outside strike range", fmt.Sprintf("above $%.2f", minStrike)
+	default:
+		return "outside strike range", fmt.Sprintf("$%.2f–$%.2f", minStrike, maxStrike)
+	}
+}
+
+// strikeBand returns the strike range scanned for the strike selection mode, widened to the
+// listed strike that reaches params.ReachStrike
+func strikeBand(strikes []float64, currentPrice float64, params ScanParams) (minStrike, maxStrike float64, otmOnly bool) {
+	mode := params.StrikeMode
+	if _, ok := defaultStrikeRanges[mode]; !ok {
+		mode = StrikeModeDollar
+	}
+
+	band := params.StrikeRange
+	if band <= 0 {
+		band = defaultStrikeRanges[mode]
+	}
+
+	otmOnly = mode == StrikeModeOTM || mode == StrikeModeDelta
+
+	switch {
+	case mode == StrikeModeDollar:
+		minStrike = currentPrice - band
+		maxStrike = currentPrice + band
+	case mode == StrikeModePercent:
+		minStrike = currentPrice * (1 - band)
+		maxStrike = currentPrice * (1 + band)
+	case params.Right == "P":
+		minStrike = currentPrice * (1 - band)
+		maxStrike = currentPrice
+	default:
+		minStrike = currentPrice
+		maxStrike = currentPrice * (1 + band)
+	}
+
+	if reach := reachStrike(strikes, params); reach > 0 {
+		minStrike = math.Min(minStrike, reach)
+		maxStrike = math.Max(maxStrike, reach)
+	}
+	return minStrike, maxStrike, otmOnly
+}
+
+// reachStrike returns the listed strike nearest params.ReachStrike on the safe side of it (at
+// or above for calls, at or below for puts), or 0 if none is set or listed
+func reachStrike(strikes []float64, params ScanParams) float64 {
+	if params.ReachStrike <= 0 {
+		return 0
+	}
+	reach, distance := 0.0, math.Inf(1)
+	for _, strike := range strikes {
+		d := strike - params.ReachStrike
+		if params.Right == "P" {
+			d = -d
+		}
+		if d >= 0 && d < distance {
+			reach, distance = strike, d
+		}
+	}
+	return reach
+}
+
+// deltaRejection checks a priced contract against the delta range in delta mode and returns
+// why it fails it, or "" if it passes
+func deltaRejection(params ScanParams, delta float64) (reason, detail string) {
+	if params.StrikeMode != StrikeModeDelta {
+		return "", ""
+	}
+
+	d := math.Abs(delta)
+	if (params.MinDelta > 0 && d < params.MinDelta) || (params.MaxDelta > 0 && d > params.MaxDelta) {
+		return "delta out of range", fmt.Sprintf("|%.2f| outside %.2f–%.2f", delta, params.MinDelta, params.MaxDelta)
+	}
+	return "", ""
+}
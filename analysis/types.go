@@ -1,23 +1,93 @@
 package analysis
 
+import (
+	"time"
+
+	"mnmlsm/web"
+)
+
 // ScanParams defines parameters for premium scanning
 type ScanParams struct {
 	Symbol      string  // Stock symbol to scan
 	Exchange    string  // Exchange (e.g., "NASDAQ", "NYSE")
 	Right       string  // "C" for calls, "P" for puts
-	StrikeRange float64 // Strike price range around current price
+	StrikeMode  string  // Strike selection: "dollar" (default), "percent", "otm" or "delta"
+	StrikeRange float64 // Dollars in dollar mode, fraction of price otherwise (0.1 = 10%); 0 = mode default
+	MinDelta    float64 // Minimum |delta| in delta mode (e.g., 0.15)
+	MaxDelta    float64 // Maximum |delta| in delta mode (e.g., 0.30)
 	MinReturn   float64 // Minimum annualized return percentage (e.g., 100 for 100%)
-	MaxDTE      int     // Maximum days to expiration
+	MinDTE      int     // Minimum days to expiration (0 = no minimum)
+	MaxDTE      int     // Maximum days to expiration (0 = no limit)
+	NumExpiries int     // Number of option months to scan (0 = front month only)
+	Workers     int     // Concurrent requests (0 = DefaultWorkers)
+	ReachStrike float64 // Widen the strike band to the listed strike at or past this price, e.g. a cost basis (0 = none)
+
+	// Liquidity
+	MinOpenInterest  int     // Minimum open interest (0 = no minimum)
+	MinVolume        int     // Minimum contracts traded today (0 = no minimum)
+	MaxSpreadPercent float64 // Maximum bid/ask spread as % of mid (0 = no limit)
+	FillMode         string  // Assumed fill: "mid" (default), "bid" or "mid-minus"
+	FillFraction     float64 // Share of the spread given up in mid-minus mode (0 = DefaultFillFraction)
+
+	// Commission schedule for net returns (nil = gross only)
+	Commissions *web.CommissionSchedule
+
+	// Event calendar and what to do with contracts spanning earnings
+	Events       []web.Event
+	EarningsMode string // "ignore" (default), "flag" or "exclude"
+
+	// Position sizing against the portfolio (nil = not sized)
+	Sizing *SizingParams
+
+	// Weighted ranking score (nil = not scored)
+	Scoring *Scorer
+
+	// Past ATM IVs of the symbol for IV rank and percentile (see web.IVWindows)
+	IVHistory []web.IVObservation
+
+	// When the market data is from; expiries, DTEs and earnings count from it
+	// (zero = the provider's capture time, now for live data)
+	AsOf time.Time
 }
 
 // BatchScanParams defines parameters for batch scanning multiple stocks
 type BatchScanParams struct {
 	SolarSystemCSV string  // Path to solar-system.csv
-	OutputCSV      string  // Path to options-chain.csv
+	Output         string  // Results file, e.g. data/options-chain.csv ("-" = stdout)
+	OutputFormat   string  // One of ResultFormats ("" = inferred from Output)
+	Exchange       string  // Exchange (defaults to "NASDAQ")
 	Right          string  // "C" for calls, "P" for puts
 	MinReturn      float64 // Minimum annualized return percentage
-	StrikeRange    float64 // Strike price range around current price (e.g., 0.1 = 10%)
-	NumExpiries    int     // Number of Friday expiries to scan (e.g., 2)
+	StrikeMode     string  // Strike selection: "dollar" (default), "percent", "otm" or "delta"
+	StrikeRange    float64 // Dollars in dollar mode, fraction of price otherwise (0.1 = 10%); 0 = mode default
+	MinDelta       float64 // Minimum |delta| in delta mode (e.g., 0.15)
+	MaxDelta       float64 // Maximum |delta| in delta mode (e.g., 0.30)
+	NumExpiries    int     // Number of option months to scan (e.g., 2)
+	MaxDTE         int     // Maximum days to expiration (0 = no limit)
+	Workers        int     // Concurrent symbols and requests (0 = DefaultWorkers)
+	SortBy         string  // Ranking key: "efficiency", "return", "pop", "touch", "iv-hv" or "breakeven"
+
+	// Liquidity (see ScanParams)
+	MinOpenInterest  int
+	MinVolume        int
+	MaxSpreadPercent float64
+	FillMode         string
+	FillFraction     float64
+
+	Commissions *web.CommissionSchedule // Commission schedule for net returns (nil = gross only)
+
+	Events       []web.Event // Event calendar (see ScanParams)
+	EarningsMode string
+
+	Sizing  *SizingParams // Position sizing (nil = not sized)
+	Scoring *Scorer       // Ranking score (nil = not scored)
+
+	IVHistory       []web.IVObservation // Past ATM IVs for IV rank (see ScanParams)
+	IVHistoryCSV    string              // File today's ATM IVs are recorded to ("" = not recorded)
+	VolSurfacesJSON string              // File each symbol's smile and term structure are saved to ("" = not saved)
+
+	HistoryDir string // Directory the run is saved to for scan-diff ("" = not saved)
+	Explain    bool   // Print each symbol's funnel and rejections
 }
 
 // OptionContract represents an option contract with calculated metrics
@@ -34,8 +104,15 @@ type OptionContract struct {
 	Bid             float64
 	Ask             float64
 	MidPrice        float64
+	FillPrice       float64 // Per-share price assumed for the sale (see ScanParams.FillMode)
 	UnderlyingPrice float64
 
+	// Liquidity
+	Spread        float64 // Ask - bid per share
+	SpreadPercent float64 // Spread as % of mid (100 for one-sided quotes)
+	OpenInterest  int
+	Volume        int
+
 	// Greeks
 	Delta      float64
 	Gamma      float64
@@ -43,15 +120,49 @@ type OptionContract struct {
 	Vega       float64
 	ImpliedVol float64
 
+	// Implied volatility of the underlying (see ScanParams.IVHistory)
+	ATMIV        float64 // At-the-money IV of the symbol today
+	IVRank       float64 // Where ATMIV sits between the window's low (0) and high (100)
+	IVPercentile float64 // % of the window's days with a lower IV
+	IVRankWindow int     // Window of IVRank and IVPercentile in days (0 = not enough history)
+	HV           float64 // 20-day close-to-close historical volatility of the symbol (0 = no history)
+	IVHVRatio    float64 // ImpliedVol / HV; above 1 the option prices more movement than realized
+
 	// Calculated metrics
 	DTE              int     // Days to expiration
-	Premium          float64 // Dollar premium (total)
+	Premium          float64 // Dollar premium at the fill price (total)
 	IntrinsicValue   float64 // Intrinsic value (ITM amount)
 	ExtrinsicValue   float64 // Extrinsic value (time premium)
 	PremiumPercent   float64 // Premium as % of strike (based on extrinsic)
 	AnnualizedReturn float64 // Annualized return % (based on extrinsic)
 	CapitalRequired  float64 // Capital required for cash-secured put/covered call
-	POP              float64 // Probability of Profit (1 - |Delta|) as percentage
-	Efficiency       float64 // Risk-adjusted return: AnnualizedReturn / (1 - POP)
+	POP              float64 // Probability of Profit: expiring beyond breakeven (lognormal model) as percentage
+	ProbAssignment   float64 // Probability of expiring in-the-money (assignment) as percentage
+	ProbTouch        float64 // Probability of the stock trading through the strike before expiry as percentage
+	Efficiency       float64 // Probability-weighted return: AnnualizedReturn × POP
 	IsITM            bool    // Whether option is in-the-money
+	EarningsDate     string  // Earnings date before expiry, if any (2006-01-02)
+
+	// Expected move to expiry (see ExpiryMove)
+	Breakeven            float64 // Stock price at expiry where the sale stops profiting (strike ∓ fill price)
+	ExpectedMove         float64 // One standard deviation move by expiry implied by the expiry's ATM IV, per share
+	ExpectedMoveStraddle float64 // ATM straddle mid by expiry, per share (0 = not quoted)
+	BreakevenMoves       float64 // Breakeven distance from the price in ExpectedMove units; below 0 = already past it
+
+	// Net of the opening commission and fees (held to expiry, so no closing order)
+	Commission          float64 // Commission and fees to sell one contract
+	NetPremium          float64 // Extrinsic value less commission (total)
+	NetAnnualizedReturn float64 // Annualized return % on net premium
+	NetEfficiency       float64 // NetAnnualizedReturn × POP
+
+	// Position sizing (see ScanParams.Sizing); limits of -1 don't bind
+	RiskPerContract        float64 // Loss of one contract under the risk model, net of premium
+	MaxContractsByRisk     int     // Contracts within the risk-per-trade limit (rule 8)
+	MaxContractsByPosition int     // Contracts within the position limit, after existing capital
+	MaxContractsByCash     int     // Contracts deployable cash can secure
+	RecommendedContracts   int     // Smallest of the limits
+
+	// Ranking (see ScanParams.Scoring)
+	Score          float64        // Weighted score 0-100
+	ScoreBreakdown ScoreBreakdown // Component values 0-100
 }
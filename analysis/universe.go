@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// UniverseUpdate is the outcome of refreshing universe.csv prices
+type UniverseUpdate struct {
+	Updated int
+	Failed  map[string]string // symbol -> error; these keep their previous price
+}
+
+// UpdateUniversePrices refreshes the price of every stock in data/universe.csv from the provider
+func UpdateUniversePrices(provider MarketDataProvider, exchange string, workers int) (*UniverseUpdate, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	// Stocks added without a price yet are priced too
+	stocks, err := readUniverse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load universe: %w", err)
+	}
+
+	update := &UniverseUpdate{Failed: make(map[string]string)}
+	var mu sync.Mutex
+
+	forEach(len(stocks), workers, func(i int) {
+		price, err := lookupPrice(provider, stocks[i].Symbol, exchange)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			update.Failed[stocks[i].Symbol] = err.Error()
+			return
+		}
+		stocks[i].Price = price
+		update.Updated++
+	})
+
+	if err := writeUniverse(stocks); err != nil {
+		return nil, fmt.Errorf("failed to write universe.csv: %w", err)
+	}
+
+	return update, nil
+}
+
+// lookupPrice resolves a symbol and returns its last price
+func lookupPrice(provider MarketDataProvider, symbol, exchange string) (float64, error) {
+	conID, err := provider.LookupUnderlying(symbol, exchange)
+	if err != nil {
+		return 0, fmt.Errorf("searching underlying: %w", err)
+	}
+
+	price, err := provider.LastPrice(conID)
+	if err != nil {
+		return 0, fmt.Errorf("getting price: %w", err)
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("got invalid price ($%.2f)", price)
+	}
+
+	return price, nil
+}
+
+// readUniverse reads every stock in data/universe.csv; stocks without a price have a Price of 0
+func readUniverse() ([]UniverseStock, error) {
+	file, err := os.Open("data/universe.csv")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var stocks []UniverseStock
+	for i, record := range records {
+		if i == 0 || len(record) < 4 {
+			continue // Skip header
+		}
+
+		price, _ := strconv.ParseFloat(record[2], 64)
+		stocks = append(stocks, UniverseStock{
+			Symbol: record[0],
+			Name:   record[1],
+			Price:  price,
+			Sector: record[3],
+		})
+	}
+
+	return stocks, nil
+}
+
+// writeUniverse writes stocks back to data/universe.csv
+func writeUniverse(stocks []UniverseStock) error {
+	file, err := os.Create("data/universe.csv")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Ticker", "Name", "Price", "Sector"}); err != nil {
+		return err
+	}
+
+	for _, stock := range stocks {
+		row := []string{
+			stock.Symbol,
+			stock.Name,
+			fmt.Sprintf("%.2f", stock.Price),
+			stock.Sector,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mnmlsm/web"
+)
+
+// DefaultVolSurfacesJSON is where the latest smile and term structure of each scanned symbol is kept
+const DefaultVolSurfacesJSON = "data/vol_surfaces.json"
+
+// volSurface builds a symbol's smiles and term structure from every contract quoted by a scan
+// at asOf. A contract quoted twice (scanned and as a straddle leg) counts once.
+func volSurface(symbol string, price float64, asOf time.Time, quotes []ivQuote) web.VolSurface {
+	type key struct {
+		maturity string
+		right    string
+		strike   float64
+	}
+	seen := make(map[key]bool)
+	points := make(map[string][]web.VolPoint)
+	dtes := make(map[string]int)
+	for _, q := range quotes {
+		k := key{q.maturity, q.right, q.strike}
+		if q.iv <= 0 || seen[k] {
+			continue
+		}
+		seen[k] = true
+		dtes[q.maturity] = q.dte
+		points[q.maturity] = append(points[q.maturity], web.VolPoint{
+			Strike: q.strike,
+			Right:  q.right,
+			Delta:  q.delta,
+			IV:     q.iv,
+			Mid:    q.mid,
+		})
+	}
+
+	var smiles []web.VolSmile
+	for maturity, expiryPoints := range points {
+		smiles = append(smiles, web.BuildVolSmile(maturity, dtes[maturity], price, expiryPoints))
+	}
+	return web.BuildVolSurface(symbol, price, asOf, smiles)
+}
+
+// recordVolSurfaces saves the surfaces of scanned symbols to params.VolSurfacesJSON; failures
+// only warn, since the scan itself succeeded
+func (p BatchScanParams) recordVolSurfaces(results []*SymbolScan) {
+	if p.VolSurfacesJSON == "" {
+		return
+	}
+	recorded, err := RecordVolSurfaces(p.VolSurfacesJSON, results)
+	if err != nil {
+		fmt.Printf("   ⚠️  Volatility surfaces not saved: %v\n", err)
+		return
+	}
+	if recorded > 0 {
+		fmt.Printf("   Volatility surfaces: %d symbols → %s\n", recorded, p.VolSurfacesJSON)
+	}
+}
+
+// RecordVolSurfaces saves the smile and term structure of each scanned symbol, replacing its
+// previous ones, and returns how many were saved
+func RecordVolSurfaces(filename string, results []*SymbolScan) (int, error) {
+	var surfaces []web.VolSurface
+	for _, result := range results {
+		if result != nil && len(result.Surface.Smiles) > 0 {
+			surfaces = append(surfaces, result.Surface)
+		}
+	}
+	if len(surfaces) == 0 {
+		return 0, nil
+	}
+	return len(surfaces), web.RecordVolSurfaces(filename, surfaces)
+}
+
+// FormatSkew formats the 25-delta put skew and term structure of a surface for progress output,
+// e.g. "Oct 24 +6.2 pts, Nov 21 +4.8 pts; term contango"
+func FormatSkew(surface web.VolSurface) string {
+	var parts []string
+	for _, smile := range surface.Smiles {
+		if smile.Put25IV <= 0 {
+			continue
+		}
+		label := smile.MaturityDate
+		if date, err := time.Parse("20060102", smile.MaturityDate); err == nil {
+			label = date.Format("Jan 2")
+		}
+		parts = append(parts, fmt.Sprintf("%s %+.1f pts", label, smile.PutSkew*100))
+	}
+
+	result := "n/a"
+	if len(parts) > 0 {
+		result = strings.Join(parts, ", ")
+	}
+	if shape := surface.TermShape(); shape != "" {
+		result += "; term " + shape
+	}
+	return result
+}
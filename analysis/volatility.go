@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"mnmlsm/ibkr"
+)
+
+// HVWindows are the lookbacks historical volatility is computed over, in trading days
+var HVWindows = []int{10, 20, 60}
+
+// DefaultHVWindow is the historical volatility contracts' IV is compared against
+const DefaultHVWindow = 20
+
+// tradingDays annualizes daily volatility
+const tradingDays = 252
+
+// HistoricalVol is the annualized realized volatility of a stock over one window, as fractions
+type HistoricalVol struct {
+	Window       int     // Trading days
+	CloseToClose float64 // Standard deviation of daily log returns (0 = not enough bars)
+	Parkinson    float64 // From daily high/low ranges; ignores overnight gaps (0 = not enough bars)
+}
+
+// fetchHistoricalVol reads a stock's daily bars from the provider and computes its historical
+// volatility. Returns nil when the provider has no price history; HV is then left out of scoring.
+func fetchHistoricalVol(provider MarketDataProvider, conID int) []HistoricalVol {
+	bars, ok := provider.(BarProvider)
+	if !ok {
+		return nil
+	}
+	history, err := bars.DailyBars(conID, HVWindows[len(HVWindows)-1]+1)
+	if err != nil {
+		return nil
+	}
+	return CalculateHistoricalVol(history)
+}
+
+// CalculateHistoricalVol computes the close-to-close and Parkinson volatility of the bars over
+// each of HVWindows. Windows longer than the history are left at 0.
+func CalculateHistoricalVol(bars []ibkr.Bar) []HistoricalVol {
+	result := make([]HistoricalVol, len(HVWindows))
+	for i, window := range HVWindows {
+		result[i] = HistoricalVol{
+			Window:       window,
+			CloseToClose: closeToCloseVol(bars, window),
+			Parkinson:    parkinsonVol(bars, window),
+		}
+	}
+	return result
+}
+
+// closeToCloseVol is the annualized sample standard deviation of the last window daily log returns
+func closeToCloseVol(bars []ibkr.Bar, window int) float64 {
+	if window < 2 || len(bars) < window+1 {
+		return 0
+	}
+	recent := bars[len(bars)-window-1:]
+
+	returns := make([]float64, 0, window)
+	mean := 0.0
+	for i := 1; i < len(recent); i++ {
+		if recent[i-1].Close <= 0 || recent[i].Close <= 0 {
+			return 0
+		}
+		r := math.Log(recent[i].Close / recent[i-1].Close)
+		returns = append(returns, r)
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	return math.Sqrt(variance * tradingDays)
+}
+
+// parkinsonVol estimates annualized volatility from the high/low range of the last window bars
+func parkinsonVol(bars []ibkr.Bar, window int) float64 {
+	if window < 1 || len(bars) < window {
+		return 0
+	}
+	recent := bars[len(bars)-window:]
+
+	sum := 0.0
+	for _, bar := range recent {
+		if bar.Low <= 0 || bar.High < bar.Low {
+			return 0
+		}
+		hl := math.Log(bar.High / bar.Low)
+		sum += hl * hl
+	}
+
+	return math.Sqrt(sum / (4 * math.Ln2 * float64(window)) * tradingDays)
+}
+
+// hvFor returns the close-to-close volatility of a window, or 0 if it wasn't computed
+func hvFor(vols []HistoricalVol, window int) float64 {
+	for _, v := range vols {
+		if v.Window == window {
+			return v.CloseToClose
+		}
+	}
+	return 0
+}
+
+// applyHV sets a contract's historical volatility and the ratio of its IV to it
+func applyHV(contract *OptionContract, vols []HistoricalVol) {
+	contract.HV = hvFor(vols, DefaultHVWindow)
+	if contract.HV > 0 && contract.ImpliedVol > 0 {
+		contract.IVHVRatio = NormalizeIV(contract.ImpliedVol) / contract.HV
+	}
+}
+
+// FormatHV formats each window as "10d 45%/40%" (close-to-close/Parkinson) for progress output
+func FormatHV(vols []HistoricalVol) string {
+	var parts []string
+	for _, v := range vols {
+		if v.CloseToClose <= 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%dd %.0f%%/%.0f%%", v.Window, v.CloseToClose*100, v.Parkinson*100))
+	}
+	if len(parts) == 0 {
+		return "n/a"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"mnmlsm/analysis"
+)
+
+// HandleCheckTrade checks a proposed trade against the rules and returns the verdict as JSON.
+// POST a ProposedTrade as JSON, or GET with the same fields as query parameters.
+func HandleCheckTrade(w http.ResponseWriter, r *http.Request) {
+	var proposed analysis.ProposedTrade
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&proposed); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+			return
+		}
+	case http.MethodGet:
+		q := r.URL.Query()
+		proposed = analysis.ProposedTrade{
+			Symbol:    q.Get("symbol"),
+			Type:      q.Get("type"),
+			Strike:    parseFloat(q.Get("strike")),
+			Expiry:    q.Get("expiry"),
+			Premium:   parseFloat(q.Get("premium")),
+			Contracts: int(parseFloat(q.Get("contracts"))),
+			Shares:    parseFloat(q.Get("shares")),
+			Price:     parseFloat(q.Get("price")),
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "use GET or POST")
+		return
+	}
+
+	check, err := analysis.CheckTrade(proposed)
+	if errors.Is(err, analysis.ErrInvalidTrade) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, check)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func parseFloat(s string) float64 {
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
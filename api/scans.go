@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"mnmlsm/analysis"
+)
+
+// HandleScans lists the saved scan runs as JSON, oldest first. Filter with ?kind=.
+func HandleScans(w http.ResponseWriter, r *http.Request) {
+	runs, err := analysis.ListScanRuns(analysis.DefaultScanHistoryDir, r.URL.Query().Get("kind"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if runs == nil {
+		runs = []analysis.ScanRunInfo{}
+	}
+
+	writeJSON(w, http.StatusOK, runs)
+}
+
+// HandleScanDiff compares two saved scan runs: ?from= and ?to= take a run ID, ID prefix,
+// "latest" or "previous" (default previous → latest), and ?kind= defaults to solar-system
+// (symbol-<SYMBOL> for single-symbol runs).
+func HandleScanDiff(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	kind := analysis.ScanKindSolarSystem
+	if q.Has("kind") {
+		kind = q.Get("kind")
+	}
+	from := q.Get("from")
+	if from == "" {
+		from = "previous"
+	}
+
+	fromRun, err := analysis.LoadScanRun(analysis.DefaultScanHistoryDir, kind, from)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	toRun, err := analysis.LoadScanRun(analysis.DefaultScanHistoryDir, kind, q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	diff, err := analysis.DiffScanRuns(fromRun, toRun)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
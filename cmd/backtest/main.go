@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"mnmlsm/analysis"
+	"mnmlsm/ibkr"
+	"mnmlsm/web"
+)
+
+func main() {
+	// Command line flags
+	symbol := flag.String("symbol", "", "Stock to backtest the wheel on (required)")
+	barsDir := flag.String("bars", analysis.DefaultBarsDir, "Directory of stored daily bars (<SYMBOL>.csv)")
+	fetch := flag.Int("fetch", 0, "Fetch this many trading days of bars and merge them into the stored history first (0 = use stored bars)")
+	snapshot := flag.String("snapshot", "", "Fetch bars from a saved chain (.json) instead of the IBKR gateway")
+	exchange := flag.String("exchange", "NASDAQ", "Exchange (NASDAQ, NYSE, etc.)")
+	start := flag.String("start", "", "First day to trade, 2006-01-02 (default first stored bar)")
+	end := flag.String("end", "", "Last day to trade, 2006-01-02 (default last stored bar)")
+	putDelta := flag.Float64("put-delta", analysis.DefaultBacktestDelta, "Target |delta| of cash-secured puts")
+	callDelta := flag.Float64("call-delta", analysis.DefaultBacktestDelta, "Target |delta| of covered calls")
+	dte := flag.Int("dte", analysis.DefaultBacktestDTE, "Days to expiry of new options (expiry on the Friday on or before)")
+	minReturn := flag.Float64("min-return", 0, "Minimum annualized % net of commission to sell an option; otherwise wait a day")
+	belowBasis := flag.Bool("below-basis", false, "Allow covered calls struck below the share cost basis")
+	roll := flag.String("roll", analysis.RollNone, "Roll policy: "+strings.Join(analysis.RollPolicies, ", "))
+	rollProfit := flag.Float64("roll-profit", analysis.DefaultRollProfitPercent, "Buy back at this % of the premium captured (profit policy)")
+	rollDTE := flag.Int("roll-dte", analysis.DefaultRollAtDTE, "Roll this many days before expiry (dte policy)")
+	contracts := flag.Int("contracts", 1, "Puts sold at a time")
+	capital := flag.Float64("capital", 0, "Starting cash (0 = enough to secure the first puts)")
+	iv := flag.Float64("iv", 0, "Implied volatility assumption, e.g. 0.45 or 45 (0 = historical volatility where there's no IV history)")
+	ivHistory := flag.String("iv-history", analysis.DefaultIVHistoryCSV, "Stored ATM IV history, used over -iv within a week of each observation (empty = don't use)")
+	commissionsFile := flag.String("commissions", "data/commissions.json", "Commission schedule for option trades")
+	outputDir := flag.String("output", "data/backtest", "Directory the ledgers are saved to as <SYMBOL>_options_transactions.csv and <SYMBOL>_stocks_transactions.csv (empty = don't save)")
+
+	flag.Parse()
+
+	if *symbol == "" {
+		fmt.Fprintf(os.Stderr, "Error: --symbol is required\n")
+		os.Exit(1)
+	}
+	*symbol = strings.ToUpper(*symbol)
+
+	// Daily bars, optionally refreshed from the gateway or a snapshot
+	bars := loadBars(*barsDir, *symbol)
+	if *fetch > 0 {
+		provider, err := analysis.OpenProvider(*snapshot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📈 Fetching %d days of %s bars...\n", *fetch, *symbol)
+		bars, err = analysis.UpdateBars(provider, *barsDir, *symbol, *exchange, *fetch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("   Stored: %d bars → %s\n\n", len(bars), analysis.BarsFile(*barsDir, *symbol))
+	}
+	if len(bars) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no bars for %s in %s (fetch them with --fetch)\n", *symbol, *barsDir)
+		os.Exit(1)
+	}
+
+	params := analysis.BacktestParams{
+		Symbol:            *symbol,
+		Bars:              bars,
+		Start:             *start,
+		End:               *end,
+		PutDelta:          *putDelta,
+		CallDelta:         *callDelta,
+		DTE:               *dte,
+		MinReturn:         *minReturn,
+		BelowBasis:        *belowBasis,
+		RollPolicy:        *roll,
+		RollProfitPercent: *rollProfit,
+		RollAtDTE:         *rollDTE,
+		Contracts:         *contracts,
+		Capital:           *capital,
+		IV:                analysis.NormalizeIV(*iv),
+	}
+	if *ivHistory != "" {
+		params.IVHistory = web.LoadIVHistory(*ivHistory)
+	}
+	commissions := web.LoadCommissionSchedule(*commissionsFile)
+	params.Commissions = &commissions
+
+	result, err := analysis.Backtest(params)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	printSummary(result)
+	printPositions(result, commissions)
+
+	if *outputDir != "" {
+		if err := saveLedgers(*outputDir, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving ledgers: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// loadBars reads a symbol's stored bars, treating a missing file as no history
+func loadBars(dir, symbol string) []ibkr.Bar {
+	bars, err := analysis.LoadBars(analysis.BarsFile(dir, symbol))
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return bars
+}
+
+// printSummary prints the performance of the backtest
+func printSummary(result *analysis.BacktestResult) {
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("           WHEEL BACKTEST: %s %s → %s\n", result.Symbol, result.Start, result.End)
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("Starting Capital:          %s\n", web.FormatCurrency(result.StartCapital))
+	fmt.Printf("Ending Equity:             %s\n", web.FormatCurrency(result.EndEquity))
+	fmt.Printf("Total Return:              %s (Ann: %s)\n", web.FormatPercentage(result.TotalReturn), web.FormatPercentage(result.AnnualizedReturn))
+	fmt.Printf("Buy & Hold Return:         %s\n", web.FormatPercentage(result.BuyHoldReturn))
+	fmt.Printf("Max Drawdown:              %s\n", web.FormatPercentage(result.MaxDrawdown))
+	fmt.Println()
+	fmt.Printf("Premium Collected:         %s\n", web.FormatCurrency(result.PremiumCollected))
+	fmt.Printf("Premium Paid:              %s\n", web.FormatCurrency(result.PremiumPaid))
+	fmt.Printf("Commissions:               %s\n", web.FormatCurrency(result.Commissions))
+	fmt.Printf("Puts / Calls Sold:         %d / %d\n", result.PutsSold, result.CallsSold)
+	fmt.Printf("Expired:                   %d\n", result.Expired)
+	fmt.Printf("Assigned / Called Away:    %d / %d\n", result.Assigned, result.CalledAway)
+	fmt.Printf("Closed Early / Rolled:     %d / %d\n", result.Closed, result.Rolled)
+	fmt.Printf("Idle Days:                 %d\n", result.IdleDays)
+
+	// Stock P&L from the same lot accounting as the portfolio
+	realized, unrealized := 0.0, 0.0
+	for _, pos := range web.CalculateAllPositions(result.Stocks, map[string]float64{result.Symbol: result.EndPrice}) {
+		if pos.Type == "open" {
+			unrealized += pos.UnrealizedPnL
+		} else {
+			realized += pos.RealizedPnL
+		}
+	}
+	fmt.Printf("Stock P/L:                 %s realized, %s unrealized (%.0f shares held)\n",
+		web.FormatCurrency(realized), web.FormatCurrency(unrealized), result.EndShares)
+
+	var sources []string
+	for _, source := range []string{analysis.IVSourceHistory, analysis.IVSourceFixed, analysis.IVSourceHV} {
+		if days := result.IVDays[source]; days > 0 {
+			sources = append(sources, fmt.Sprintf("%s %d days", source, days))
+		}
+	}
+	if len(sources) > 0 {
+		fmt.Printf("IV Source:                 %s\n", strings.Join(sources, ", "))
+	}
+	fmt.Println()
+}
+
+// printPositions lists each simulated option position as the portfolio analytics see it, with
+// covered call capital from the simulated assignments rather than the portfolio's holdings
+func printPositions(result *analysis.BacktestResult, commissions web.CommissionSchedule) {
+	positions := web.CalculateOptionPositionsFrom(result.Options, result.Stocks, commissions, nil)
+	if len(positions) == 0 {
+		fmt.Println("No options sold")
+		return
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].OpenDate != positions[j].OpenDate {
+			return positions[i].OpenDate < positions[j].OpenDate
+		}
+		return positions[i].PositionID < positions[j].PositionID
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tSTRIKE\tOPENED\tEXPIRY\tCLOSED\tSTATUS\tNET\tANN %")
+	fmt.Fprintln(w, "--\t----\t------\t------\t------\t------\t------\t---\t-----")
+	for _, pos := range positions {
+		fmt.Fprintf(w, "%s\t%s\t$%.2f\t%s\t%s\t%s\t%s\t%s\t%.1f\n",
+			pos.PositionID, pos.OptionType, pos.Strike, pos.OpenDate, pos.Expiry, pos.CloseDate,
+			pos.Status, web.FormatCurrency(pos.NetPremium), pos.AnnualizedReturn)
+	}
+	w.Flush()
+	fmt.Println()
+}
+
+// saveLedgers writes the simulated trades as option and stock transaction CSVs
+func saveLedgers(dir string, result *analysis.BacktestResult) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	optionsFile := filepath.Join(dir, result.Symbol+"_options_transactions.csv")
+	if err := web.SaveOptionTransactions(optionsFile, result.Options); err != nil {
+		return err
+	}
+	stocksFile := filepath.Join(dir, result.Symbol+"_stocks_transactions.csv")
+	if err := web.SaveStockTransactions(stocksFile, result.Stocks); err != nil {
+		return err
+	}
+
+	fmt.Printf("   Ledger: %s, %s\n", optionsFile, stocksFile)
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"mnmlsm/analysis"
+)
+
+func main() {
+	// Command line flags
+	symbol := flag.String("symbol", "", "Stock symbol (required)")
+	tradeType := flag.String("type", analysis.TradePut, "Trade: put (sell cash-secured put), call (sell covered call) or stock (buy shares)")
+	strike := flag.Float64("strike", 0, "Option strike")
+	expiry := flag.String("expiry", "", "Option expiry (2006-01-02)")
+	premium := flag.Float64("premium", 0, "Option premium per share")
+	contracts := flag.Int("contracts", 1, "Option contracts")
+	shares := flag.Float64("shares", 0, "Shares to buy")
+	price := flag.Float64("price", 0, "Stock price (0 = last price in universe.csv)")
+	format := flag.String("format", "table", "Output format (table or json)")
+
+	flag.Parse()
+
+	if *symbol == "" {
+		fmt.Fprintf(os.Stderr, "Error: --symbol is required\n")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	check, err := analysis.CheckTrade(analysis.ProposedTrade{
+		Symbol:    *symbol,
+		Type:      *tradeType,
+		Strike:    *strike,
+		Expiry:    *expiry,
+		Premium:   *premium,
+		Contracts: *contracts,
+		Shares:    *shares,
+		Price:     *price,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		output, _ := json.MarshalIndent(check, "", "  ")
+		fmt.Println(string(output))
+	} else {
+		printCheck(check)
+	}
+
+	// Non-zero exit so scripts can stop on a failing trade
+	if check.Status == analysis.CheckFail {
+		os.Exit(2)
+	}
+}
+
+func printCheck(check *analysis.TradeCheck) {
+	t := check.Trade
+	switch t.Type {
+	case analysis.TradeStock:
+		fmt.Printf("🔍 Checking: buy %.0f %s @ $%.2f ($%.0f)\n\n", t.Shares, t.Symbol, t.Price, check.Capital)
+	default:
+		fmt.Printf("🔍 Checking: sell %d %s $%.2f %s exp %s @ $%.2f ($%.0f secured)\n\n",
+			t.Contracts, t.Symbol, t.Strike, t.Type, t.Expiry, t.Premium, check.Capital)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tRULE\tDETAIL")
+	fmt.Fprintln(w, "------\t----\t------")
+	for _, c := range check.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", statusIcon(c.Status), c.Rule, c.Message)
+	}
+	w.Flush()
+
+	fmt.Println()
+	switch check.Status {
+	case analysis.CheckFail:
+		fmt.Println("❌ Trade breaks the rules")
+	case analysis.CheckWarn:
+		fmt.Println("⚠️  Trade passes with warnings")
+	default:
+		fmt.Println("✅ Trade passes all rules")
+	}
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case analysis.CheckFail:
+		return "❌ fail"
+	case analysis.CheckWarn:
+		return "⚠️  warn"
+	default:
+		return "✅ pass"
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"text/tabwriter"
 
 	"mnmlsm/analysis"
+	"mnmlsm/web"
 )
 
 func main() {
@@ -22,16 +23,49 @@ func main() {
 	fmt.Printf("📊 Portfolio Metrics:\n")
 	fmt.Printf("   Total Net Worth:  %s\n", formatCurrency(result.TotalNetWorth))
 	fmt.Printf("   Dry Powder:       %s\n", formatCurrency(result.DryPowder))
-	fmt.Printf("   10%% Position Max: %s\n", formatCurrency(result.TotalNetWorth*0.10))
-	fmt.Printf("   20%% Sector Max:   %s\n", formatCurrency(result.TotalNetWorth*0.20))
+	fmt.Println()
+
+	// Print the cash reserve
+	fmt.Printf("🛡️  Cash Reserve:\n")
+	if result.VIXRegime != "" {
+		fmt.Printf("   VIX:              %.2f (%s)\n", result.VIX, result.VIXRegime)
+	} else {
+		fmt.Printf("   VIX:              %.2f\n", result.VIX)
+	}
+	fmt.Printf("   Reserve:          %s (%.0f%%)\n", formatCurrency(result.Reserve), result.ReservePercent)
+	fmt.Printf("   Deployable:       %s\n", formatCurrency(result.Deployable))
+	fmt.Println()
+
+	// Print the rules the filters ran with
+	fmt.Printf("📏 Rules:\n")
+	for _, rule := range []struct {
+		id    string
+		label string
+	}{
+		{web.RuleCashMinimum, "Cash Minimum"},
+		{web.RuleMaxPosition, "Position Max"},
+		{web.RuleMaxSector, "Sector Max"},
+	} {
+		if !result.Rules.Enabled(rule.id) {
+			fmt.Printf("   %-18s disabled\n", rule.label+":")
+			continue
+		}
+		threshold := result.Rules.Threshold(rule.id)
+		fmt.Printf("   %-18s %.0f%% = %s\n", rule.label+":", threshold, formatCurrency(result.TotalNetWorth*threshold/100))
+	}
+	if result.Rules.Enabled(web.RuleNoEarnings) {
+		fmt.Printf("   %-18s next %.0f days\n", "No Earnings:", result.Rules.Threshold(web.RuleNoEarnings))
+	} else {
+		fmt.Printf("   %-18s disabled\n", "No Earnings:")
+	}
 	fmt.Println()
 
 	// Print survivors
 	fmt.Printf("✅ %d stocks passed all filters:\n\n", len(result.Survivors))
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "SYMBOL\tPRICE\tSECTOR\tPOS COST\tPOS%\tSECTOR%\tSTOCK\tPUT\tEXISTING CAP")
-	fmt.Fprintln(w, "------\t-----\t------\t--------\t----\t-------\t-----\t---\t------------")
+	fmt.Fprintln(w, "SYMBOL\tPRICE\tSECTOR\tPOS COST\tPOS%\tSECTOR%\tSTOCK\tPUT\tEXISTING CAP\tATM IV\tIV RANK")
+	fmt.Fprintln(w, "------\t-----\t------\t--------\t----\t-------\t-----\t---\t------------\t------\t-------")
 
 	for _, c := range result.Survivors {
 		stockFlag := " "
@@ -44,7 +78,12 @@ func main() {
 			putFlag = "✓"
 		}
 
-		fmt.Fprintf(w, "%s\t$%.2f\t%s\t$%.0f\t%.1f%%\t%.1f%%\t%s\t%s\t$%.0f\n",
+		atmIV := "-"
+		if c.IV.Current > 0 {
+			atmIV = fmt.Sprintf("%.0f%%", c.IV.Current*100)
+		}
+
+		fmt.Fprintf(w, "%s\t$%.2f\t%s\t$%.0f\t%.1f%%\t%.1f%%\t%s\t%s\t$%.0f\t%s\t%s\n",
 			c.Symbol,
 			c.Price,
 			truncate(c.Sector, 15),
@@ -54,6 +93,8 @@ func main() {
 			stockFlag,
 			putFlag,
 			c.ExistingCapital,
+			atmIV,
+			c.IV.FormatRank(),
 		)
 	}
 	w.Flush()
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mnmlsm/analysis"
+	"mnmlsm/web"
+)
+
+func main() {
+	// Command line flags
+	eventsFile := flag.String("events", "data/events.csv", "Event calendar CSV")
+	importFile := flag.String("import", "", "Merge events from a CSV file (Symbol, Date, Type, Notes)")
+	source := flag.String("source", "", "Merge upcoming earnings and ex-dividend dates from a market data source: ibkr (the gateway)")
+	symbolList := flag.String("symbols", "", "Comma-separated symbols for -source (default every stock in data/universe.csv)")
+	exchange := flag.String("exchange", "NASDAQ", "Exchange for -source (NASDAQ, NYSE, etc.)")
+	workers := flag.Int("workers", analysis.DefaultWorkers, "Concurrent IBKR requests for -source")
+	add := flag.String("add", "", "Add one event as SYMBOL,DATE[,TYPE[,NOTES]] (type defaults to Earnings)")
+	symbol := flag.String("symbol", "", "Only list events for this symbol")
+	days := flag.Int("days", 30, "List events in the next N days")
+
+	flag.Parse()
+
+	events := web.LoadEvents(*eventsFile)
+
+	// Import and add
+	var imported []web.Event
+	if *importFile != "" {
+		fromFile, err := web.ReadEventsCSV(*importFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *importFile, err)
+			os.Exit(1)
+		}
+		imported = append(imported, fromFile...)
+	}
+	if *source != "" {
+		if *source != "ibkr" {
+			fmt.Fprintf(os.Stderr, "Error: --source must be 'ibkr'\n")
+			os.Exit(1)
+		}
+		var symbols []string
+		for _, s := range strings.Split(*symbolList, ",") {
+			if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+				symbols = append(symbols, s)
+			}
+		}
+
+		provider, err := analysis.OpenProvider("")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("📅 Fetching upcoming events from IBKR...")
+		fromSource, err := analysis.ImportEvents(provider, symbols, *exchange, *workers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for symbol, reason := range fromSource.Failed {
+			fmt.Printf("   ❌ %s: %s\n", symbol, reason)
+		}
+		imported = append(imported, fromSource.Events...)
+	}
+	if *add != "" {
+		event, err := parseAdd(*add)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		imported = append(imported, event)
+	}
+
+	if len(imported) > 0 {
+		before := len(events)
+		events = web.MergeEvents(events, imported)
+		if err := web.SaveEvents(*eventsFile, events); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving %s: %v\n", *eventsFile, err)
+			os.Exit(1)
+		}
+		fmt.Printf("📅 Imported %d events (%d new), %d in calendar\n\n", len(imported), len(events)-before, len(events))
+	}
+
+	// List upcoming events
+	var upcoming []web.Event
+	if *symbol != "" {
+		upcoming = web.UpcomingEvents(events, strings.ToUpper(*symbol), *days)
+	} else {
+		symbols := make(map[string]bool)
+		for _, e := range events {
+			symbols[e.Symbol] = true
+		}
+		for s := range symbols {
+			upcoming = append(upcoming, web.UpcomingEvents(events, s, *days)...)
+		}
+		sort.Slice(upcoming, func(i, j int) bool {
+			if upcoming[i].Date != upcoming[j].Date {
+				return upcoming[i].Date < upcoming[j].Date
+			}
+			return upcoming[i].Symbol < upcoming[j].Symbol
+		})
+	}
+
+	if len(upcoming) == 0 {
+		fmt.Printf("No events in the next %d days\n", *days)
+		return
+	}
+
+	fmt.Printf("📅 %d events in the next %d days:\n\n", len(upcoming), *days)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tDATE\tIN\tTYPE\tNOTES")
+	fmt.Fprintln(w, "------\t----\t--\t----\t-----")
+
+	today := time.Now().Truncate(24 * time.Hour)
+	for _, e := range upcoming {
+		daysAway := int(e.Time().Sub(today).Hours() / 24)
+		marker := ""
+		if e.IsEarnings() {
+			marker = " ⚠️"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%dd\t%s%s\t%s\n", e.Symbol, e.Date, daysAway, e.Type, marker, e.Notes)
+	}
+	w.Flush()
+}
+
+// parseAdd parses SYMBOL,DATE[,TYPE[,NOTES]] into an event
+func parseAdd(value string) (web.Event, error) {
+	parts := strings.SplitN(value, ",", 4)
+	if len(parts) < 2 {
+		return web.Event{}, fmt.Errorf("-add expects SYMBOL,DATE[,TYPE[,NOTES]], got %q", value)
+	}
+
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(parts[1]))
+	if err != nil {
+		return web.Event{}, fmt.Errorf("parsing date %q: %w", parts[1], err)
+	}
+
+	event := web.Event{
+		Symbol: strings.ToUpper(strings.TrimSpace(parts[0])),
+		Date:   date.Format("2006-01-02"),
+		Type:   web.EventEarnings,
+	}
+	if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+		event.Type = strings.TrimSpace(parts[2])
+	}
+	if len(parts) > 3 {
+		event.Notes = strings.TrimSpace(parts[3])
+	}
+	return event, nil
+}
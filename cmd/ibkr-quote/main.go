@@ -1,13 +1,12 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"mnmlsm/analysis"
 	"mnmlsm/ibkr"
+	"mnmlsm/web"
 	"os"
-	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -20,10 +19,34 @@ func main() {
 	premiumScan := flag.Bool("premium-scan", false, "Scan for premium opportunities")
 	minReturn := flag.Float64("min-return", 100, "Minimum annualized return % for premium scan")
 	maxDTE := flag.Int("max-dte", 4, "Maximum days to expiration")
-	strikeRange := flag.Float64("strike-range", 5, "Strike price range around current price")
+	strikeMode := flag.String("strike-mode", analysis.StrikeModeDollar, "Strike selection: dollar, percent, otm or delta")
+	strikeRange := flag.Float64("strike-range", 0, "Strike range: dollars in dollar mode (5 = $5), fraction of price otherwise (0.1 = 10%); 0 = mode default ($5, 10%, all OTM, 30%)")
+	minDelta := flag.Float64("min-delta", 0.15, "Minimum |delta| in delta mode")
+	maxDelta := flag.Float64("max-delta", 0.30, "Maximum |delta| in delta mode")
+	minOI := flag.Int("min-oi", 0, "Minimum open interest (0 = no minimum)")
+	minVolume := flag.Int("min-volume", 0, "Minimum contracts traded today (0 = no minimum)")
+	maxSpread := flag.Float64("max-spread", 25, "Maximum bid/ask spread as % of mid (0 = no limit)")
+	fill := flag.String("fill", analysis.FillMid, "Assumed fill price: mid, bid or mid-minus")
+	fillFraction := flag.Float64("fill-fraction", analysis.DefaultFillFraction, "Share of the spread given up in mid-minus mode")
 	right := flag.String("right", "P", "Option type: C (call) or P (put)")
 	exchange := flag.String("exchange", "NASDAQ", "Exchange (NASDAQ, NYSE, etc.)")
-	csvOutput := flag.String("csv", "", "Output results to CSV file")
+	output := flag.String("output", "", "Save results to this file; - streams them to stdout")
+	outputFormat := flag.String("output-format", "", "Results format: "+strings.Join(analysis.ResultFormats, ", ")+" (default from the -output extension; ndjson for -)")
+	csvOutput := flag.String("csv", "", "Save results to this CSV file (same as -output with -output-format csv)")
+	snapshot := flag.String("snapshot", "", "Scan a saved chain (.json or .csv) instead of the IBKR gateway")
+	saveSnapshot := flag.String("save-snapshot", "", "Save the scanned chain to this file (.json or .csv)")
+	explain := flag.Bool("explain", false, "Print the scan funnel (strikes → contracts → priced → passed) and why strikes and contracts were rejected")
+	historyDir := flag.String("history", analysis.DefaultScanHistoryDir, "Directory to save premium scans to for scan-diff, except from a -snapshot (empty = don't save)")
+	sortBy := flag.String("sort", "score", "Rank contracts by: score, efficiency, net, return, pop, touch, iv-hv or breakeven")
+	scorePreset := flag.String("score", analysis.DefaultScorePreset, "Score preset: "+strings.Join(analysis.ScorePresetNames(), ", "))
+	scoreWeights := flag.String("weights", "", "Score weight overrides, e.g. return=4,liquidity=0 (components: "+strings.Join(analysis.ScoreComponents, ", ")+")")
+	eventsFile := flag.String("events", "data/events.csv", "Event calendar CSV")
+	ivHistory := flag.String("iv-history", analysis.DefaultIVHistoryCSV, "ATM IV history for IV rank; today's ATM IV is recorded to it, except from a -snapshot (empty = neither)")
+	volSurfaces := flag.String("vol-surfaces", analysis.DefaultVolSurfacesJSON, "File the smile and term structure are saved to for the volatility page, except from a -snapshot (empty = don't save)")
+	earnings := flag.String("earnings", analysis.EarningsExclude, "Contracts spanning earnings: exclude, flag or ignore")
+	commissionsFile := flag.String("commissions", "data/commissions.json", "Commission schedule for net returns")
+	numExpiries := flag.Int("expiries", 1, "Number of option months to scan")
+	workers := flag.Int("workers", analysis.DefaultWorkers, "Concurrent IBKR requests")
 	flag.Parse()
 
 	if *symbol == "" {
@@ -32,15 +55,66 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create IBKR client
-	client := ibkr.NewClient()
+	if *csvOutput != "" && *output == "" {
+		*output, *outputFormat = *csvOutput, analysis.FormatCSV
+	}
 
 	if *premiumScan {
+		// Streamed results get stdout to themselves; progress goes to stderr
+		if *output == "-" {
+			os.Stdout = os.Stderr
+		}
+
 		// Run premium scan
-		runPremiumScan(client, *symbol, *exchange, *right, *strikeRange, *minReturn, *maxDTE, *csvOutput)
+		params := analysis.ScanParams{
+			Symbol:      *symbol,
+			Exchange:    *exchange,
+			Right:       *right,
+			StrikeMode:  *strikeMode,
+			StrikeRange: *strikeRange,
+			MinDelta:    *minDelta,
+			MaxDelta:    *maxDelta,
+			MinReturn:   *minReturn,
+			MaxDTE:      *maxDTE,
+			NumExpiries: *numExpiries,
+			Workers:     *workers,
+
+			MinOpenInterest:  *minOI,
+			MinVolume:        *minVolume,
+			MaxSpreadPercent: *maxSpread,
+			FillMode:         *fill,
+			FillFraction:     *fillFraction,
+		}
+
+		// Commission schedule for net-of-fee returns
+		commissions := web.LoadCommissionSchedule(*commissionsFile)
+		params.Commissions = &commissions
+
+		// Event calendar for the earnings check
+		params.Events = web.LoadEvents(*eventsFile)
+		params.EarningsMode = *earnings
+
+		// IV history for IV rank
+		if *ivHistory != "" {
+			params.IVHistory = web.LoadIVHistory(*ivHistory)
+		}
+
+		// Ranking score, with sector diversification against the portfolio when it loads
+		weights, err := analysis.ParseScoreWeights(*scorePreset, *scoreWeights)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		rules := web.LoadTradingRules("data/rules.json")
+		portfolio, _ := analysis.LoadPortfolioSnapshot(rules)
+		params.Scoring = &analysis.Scorer{
+			Weights: weights,
+			Context: analysis.NewScoreContext(portfolio, rules, params.Events),
+		}
+		runPremiumScan(params, *snapshot, *saveSnapshot, *output, *outputFormat, *sortBy, *historyDir, *ivHistory, *volSurfaces, *explain)
 	} else {
 		// Get single quote
-		runQuote(client, *symbol, *format)
+		runQuote(ibkr.NewClient(), *symbol, *format)
 	}
 }
 
@@ -60,52 +134,106 @@ func runQuote(client *ibkr.Client, symbol, format string) {
 	}
 }
 
-func runPremiumScan(client *ibkr.Client, symbol, exchange, right string, strikeRange, minReturn float64, maxDTE int, csvFile string) {
-	fmt.Printf("🔍 Scanning %s %s options for premium opportunities...\n\n", symbol, right)
+func runPremiumScan(params analysis.ScanParams, snapshot, saveSnapshot, output, outputFormat, sortBy, historyDir, ivHistory, volSurfaces string, explain bool) {
+	fmt.Printf("🔍 Scanning %s %s options for premium opportunities...\n\n", params.Symbol, params.Right)
 
-	// Create scanner
-	scanner := analysis.NewScanner(client)
+	// Create market data provider (IBKR gateway or saved snapshot)
+	provider, err := analysis.OpenProvider(snapshot)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Set up scan parameters
-	params := analysis.ScanParams{
-		Symbol:      symbol,
-		Exchange:    exchange,
-		Right:       right,
-		StrikeRange: strikeRange,
-		MinReturn:   minReturn,
-		MaxDTE:      maxDTE,
+	// Record the chain while scanning if requested
+	var recorder *analysis.RecordingProvider
+	if saveSnapshot != "" {
+		recorder = analysis.NewRecordingProvider(provider)
+		provider = recorder
 	}
 
+	// Create scanner
+	scanner := analysis.NewScanner(provider)
+
 	fmt.Println("1. Searching for underlying...")
 
 	// Run scan with progress tracking
-	contracts, err := scanner.ScanPremiums(params)
+	start := time.Now()
+	result, err := scanner.ScanSymbol(params)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
+	contracts := result.Contracts
+
+	// A saved chain isn't today's market, so it stays out of the scan history, IV history and surfaces
+	if historyDir != "" && snapshot == "" {
+		run := analysis.NewScanRun(analysis.SymbolScanKind(params.Symbol), start, params, []string{params.Symbol}, contracts)
+		if path, err := analysis.SaveScanRun(historyDir, run); err != nil {
+			fmt.Printf("Error saving scan history: %v\n", err)
+		} else {
+			fmt.Printf("💾 History saved to %s\n", path)
+		}
+	}
+
+	if ivHistory != "" && snapshot == "" {
+		if _, err := analysis.RecordATMIVs(ivHistory, []*analysis.SymbolScan{result}); err != nil {
+			fmt.Printf("Error saving IV history: %v\n", err)
+		}
+	}
+
+	if volSurfaces != "" && snapshot == "" {
+		if _, err := analysis.RecordVolSurfaces(volSurfaces, []*analysis.SymbolScan{result}); err != nil {
+			fmt.Printf("Error saving volatility surface: %v\n", err)
+		}
+	}
+
+	if recorder != nil {
+		if err := analysis.SaveSnapshot(saveSnapshot, recorder.Recorded()); err != nil {
+			fmt.Printf("Error saving snapshot: %v\n", err)
+		} else {
+			fmt.Printf("💾 Snapshot saved to %s\n", saveSnapshot)
+		}
+	}
 
 	fmt.Printf("\n5. Analyzing %d contracts...\n", len(contracts))
+	if result.ATMIV > 0 {
+		fmt.Printf("   ATM IV: %.0f%%, IV rank %s\n", result.ATMIV*100, result.IV.FormatRank())
+	}
+	if result.HV != nil {
+		fmt.Printf("   HV (close/Parkinson): %s\n", analysis.FormatHV(result.HV))
+	}
+	if len(result.ExpectedMoves) > 0 {
+		fmt.Printf("   Expected move: %s\n", analysis.FormatExpiryMoves(result.ExpectedMoves))
+	}
+	if len(result.Surface.Smiles) > 0 {
+		fmt.Printf("   25Δ put skew: %s\n", analysis.FormatSkew(result.Surface))
+	}
 
-	if len(contracts) == 0 {
-		fmt.Printf("\nNo contracts found meeting criteria (>%.0f%% annualized, ≤%d DTE)\n", minReturn, maxDTE)
-		return
+	if explain {
+		fmt.Println()
+		analysis.PrintExplain(result)
 	}
 
-	// Sort by annualized return (highest first)
-	sort.Slice(contracts, func(i, j int) bool {
-		return contracts[i].AnnualizedReturn > contracts[j].AnnualizedReturn
-	})
+	// Rank contracts (weighted score by default)
+	analysis.SortContracts(contracts, sortBy)
 
-	// Display results
-	printPremiumTable(contracts)
+	if len(contracts) == 0 {
+		fmt.Printf("\nNo contracts found meeting criteria (>%.0f%% annualized, ≤%d DTE)\n", params.MinReturn, params.MaxDTE)
+		if !explain {
+			fmt.Println("Run with -explain to see where strikes and contracts dropped out")
+		}
+	} else {
+		printPremiumTable(contracts)
+	}
 
-	// Save to CSV if requested
-	if csvFile != "" {
-		if err := savePremiumsToCSV(contracts, csvFile); err != nil {
-			fmt.Printf("\nError saving to CSV: %v\n", err)
-		} else {
-			fmt.Printf("\n✅ Results saved to %s\n", csvFile)
+	// Save results if requested (an empty scan still writes the header)
+	if output != "" {
+		if err := analysis.WriteContracts(outputFormat, output, contracts); err != nil {
+			fmt.Printf("\nError saving results: %v\n", err)
+			os.Exit(1)
+		}
+		if output != "-" {
+			fmt.Printf("\n✅ Results saved to %s\n", output)
 		}
 	}
 }
@@ -152,8 +280,8 @@ func printPremiumTable(contracts []analysis.OptionContract) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
 	fmt.Fprintf(w, "\n✅ Found %d qualifying contracts:\n\n", len(contracts))
-	fmt.Fprintln(w, "STRIKE\tEXPIRY\tDTE\tEXTRINSIC\tANN%\tPOP\tEFFICIENCY\tITM\tDELTA\tCAPITAL")
-	fmt.Fprintln(w, strings.Repeat("-", 120))
+	fmt.Fprintln(w, "STRIKE\tEXPIRY\tDTE\tEXTRINSIC\tANN%\tNET%\tPOP\tP(ASSIGN)\tP(TOUCH)\tBREAKEVEN\tBE/MOVE\tEFFICIENCY\tSCORE\tBREAKDOWN\tITM\tDELTA\tIV/HV\tSPREAD%\tOI\tVOL\tCAPITAL\tEARNINGS")
+	fmt.Fprintln(w, strings.Repeat("-", 210))
 
 	for _, c := range contracts {
 		// Parse expiry date for display
@@ -165,77 +293,41 @@ func printPremiumTable(contracts []analysis.OptionContract) {
 			itmStr = "ITM"
 		}
 
-		fmt.Fprintf(w, "$%.2f\t%s\t%dd\t$%.0f\t%.0f%%\t%.1f%%\t%.0f\t%s\t%.3f\t$%.0f\n",
+		breakevenMoves := "-"
+		if c.ExpectedMove > 0 {
+			breakevenMoves = fmt.Sprintf("%.1f", c.BreakevenMoves)
+		}
+
+		ivhv := "-"
+		if c.IVHVRatio > 0 {
+			ivhv = fmt.Sprintf("%.2f", c.IVHVRatio)
+		}
+
+		fmt.Fprintf(w, "$%.2f\t%s\t%dd\t$%.0f\t%.0f%%\t%.0f%%\t%.1f%%\t%.1f%%\t%.1f%%\t$%.2f\t%s\t%.0f\t%.0f\t%s\t%s\t%.3f\t%s\t%.0f%%\t%d\t%d\t$%.0f\t%s\n",
 			c.Strike,
 			expiryStr,
 			c.DTE,
 			c.ExtrinsicValue,
 			c.AnnualizedReturn,
+			c.NetAnnualizedReturn,
 			c.POP,
+			c.ProbAssignment,
+			c.ProbTouch,
+			c.Breakeven,
+			breakevenMoves,
 			c.Efficiency,
+			c.Score,
+			c.ScoreBreakdown,
 			itmStr,
 			c.Delta,
+			ivhv,
+			c.SpreadPercent,
+			c.OpenInterest,
+			c.Volume,
 			c.CapitalRequired,
+			c.EarningsDate,
 		)
 	}
 
 	w.Flush()
 }
-
-func savePremiumsToCSV(contracts []analysis.OptionContract, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	header := []string{
-		"Symbol", "Strike", "Expiry", "DTE", "Premium", "Intrinsic", "Extrinsic",
-		"Premium%", "Annualized%", "POP%", "Efficiency", "ITM", "Delta", "Gamma", "Theta",
-		"Vega", "IV", "Bid", "Ask", "Capital", "ConID",
-	}
-	if err := writer.Write(header); err != nil {
-		return err
-	}
-
-	// Write rows
-	for _, c := range contracts {
-		itmStr := "false"
-		if c.IsITM {
-			itmStr = "true"
-		}
-
-		row := []string{
-			c.Symbol,
-			fmt.Sprintf("%.2f", c.Strike),
-			c.MaturityDate,
-			fmt.Sprintf("%d", c.DTE),
-			fmt.Sprintf("%.2f", c.Premium),
-			fmt.Sprintf("%.2f", c.IntrinsicValue),
-			fmt.Sprintf("%.2f", c.ExtrinsicValue),
-			fmt.Sprintf("%.2f", c.PremiumPercent),
-			fmt.Sprintf("%.2f", c.AnnualizedReturn),
-			fmt.Sprintf("%.2f", c.POP),
-			fmt.Sprintf("%.2f", c.Efficiency),
-			itmStr,
-			fmt.Sprintf("%.4f", c.Delta),
-			fmt.Sprintf("%.4f", c.Gamma),
-			fmt.Sprintf("%.4f", c.Theta),
-			fmt.Sprintf("%.4f", c.Vega),
-			fmt.Sprintf("%.2f", c.ImpliedVol),
-			fmt.Sprintf("%.2f", c.Bid),
-			fmt.Sprintf("%.2f", c.Ask),
-			fmt.Sprintf("%.2f", c.CapitalRequired),
-			fmt.Sprintf("%d", c.ConID),
-		}
-		if err := writer.Write(row); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
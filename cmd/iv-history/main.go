@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"mnmlsm/analysis"
+	"mnmlsm/web"
+)
+
+func main() {
+	historyFile := flag.String("history", analysis.DefaultIVHistoryCSV, "ATM IV history CSV")
+	symbolList := flag.String("symbols", "", "Comma-separated symbols (default every stock in data/universe.csv)")
+	exchange := flag.String("exchange", "NASDAQ", "Exchange (NASDAQ, NYSE, etc.)")
+	workers := flag.Int("workers", analysis.DefaultWorkers, "Concurrent symbols and IBKR requests")
+	snapshot := flag.String("snapshot", "", "Read IVs from a saved chain (.json or .csv) instead of the IBKR gateway")
+	show := flag.Bool("show", false, "Only show IV rank and percentile from the saved history, without collecting")
+
+	flag.Parse()
+
+	var symbols []string
+	for _, symbol := range strings.Split(*symbolList, ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if !*show {
+		provider, err := analysis.OpenProvider(*snapshot)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("📈 Recording ATM implied volatility...")
+		collection, err := analysis.CollectIVHistory(provider, *historyFile, symbols, *exchange, *workers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("   Recorded: %d symbols → %s\n", len(collection.IVs), *historyFile)
+		for symbol, reason := range collection.Failed {
+			fmt.Printf("   ❌ %s: %s\n", symbol, reason)
+		}
+		fmt.Println()
+	}
+
+	printRanks(web.LoadIVHistory(*historyFile), symbols)
+}
+
+// printRanks prints each symbol's latest IV with its rank and percentile per window
+func printRanks(history []web.IVObservation, symbols []string) {
+	if len(symbols) == 0 {
+		seen := make(map[string]bool)
+		for _, o := range history {
+			if !seen[o.Symbol] {
+				seen[o.Symbol] = true
+				symbols = append(symbols, o.Symbol)
+			}
+		}
+		sort.Strings(symbols)
+	}
+
+	header := []string{"SYMBOL", "DATE", "ATM IV"}
+	for _, window := range web.IVWindows {
+		header = append(header, fmt.Sprintf("RANK %dD", window), fmt.Sprintf("PCTL %dD", window))
+	}
+	separator := make([]string, len(header))
+	for i, column := range header {
+		separator[i] = strings.Repeat("-", len(column))
+	}
+
+	fmt.Printf("📊 IV rank and percentile (need %d days of history):\n\n", web.MinIVObservations)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	fmt.Fprintln(w, strings.Join(separator, "\t"))
+
+	for _, symbol := range symbols {
+		stats, ok := web.CalculateIVStats(history, symbol)
+		if !ok {
+			fmt.Fprintf(w, "%s\t-\t-\n", symbol)
+			continue
+		}
+
+		row := []string{symbol, stats.Date, web.FormatIV(stats.Current)}
+		for _, window := range stats.Windows {
+			if !window.Ready() {
+				row = append(row, fmt.Sprintf("- (%dd)", window.Observations), "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.0f", window.Rank), fmt.Sprintf("%.0f", window.Percentile))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
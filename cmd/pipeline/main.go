@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"mnmlsm/analysis"
+)
+
+func main() {
+	configFile := flag.String("config", "data/pipeline.json", "Pipeline config (scan, sizing and shortlist settings)")
+	snapshot := flag.String("snapshot", "", "Run against a saved chain (.json or .csv) instead of the IBKR gateway")
+	saveSnapshot := flag.String("save-snapshot", "", "Save the scanned chain to this file (.json or .csv)")
+	noUpdate := flag.Bool("no-update", false, "Skip refreshing universe.csv prices (always skipped with -snapshot)")
+	explain := flag.Bool("explain", false, "Print each symbol's scan funnel and why strikes and contracts were rejected")
+	top := flag.Int("top", -1, "Contracts in the shortlist (overrides the config; 0 = all)")
+
+	flag.Parse()
+
+	config, err := analysis.LoadPipelineConfig(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *noUpdate || *snapshot != "" {
+		config.UpdateUniverse = false
+	}
+	if *explain {
+		config.Explain = true
+	}
+	if *top >= 0 {
+		config.ShortlistSize = *top
+	}
+
+	// Create market data provider (IBKR gateway or saved snapshot)
+	provider, err := analysis.OpenProvider(*snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Record the chain while scanning if requested
+	var recorder *analysis.RecordingProvider
+	if *saveSnapshot != "" {
+		recorder = analysis.NewRecordingProvider(provider)
+		provider = recorder
+	}
+
+	result, err := analysis.RunPipeline(provider, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if recorder != nil {
+		if err := analysis.SaveSnapshot(*saveSnapshot, recorder.Recorded()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving snapshot: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	elimination := result.Elimination
+	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Printf("🎯 Shortlist (%d of %d contracts, ranked by %s)\n", len(result.Shortlist), len(result.Contracts), config.SortBy)
+	fmt.Printf("   Net Worth: $%.0f, Deployable: $%.0f above $%.0f reserve (%.0f%%)\n\n",
+		elimination.TotalNetWorth, elimination.Deployable, elimination.Reserve, elimination.ReservePercent)
+
+	if len(result.Shortlist) == 0 {
+		fmt.Println("   No contracts fit the rules and the cash available.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tSYMBOL\tSTRIKE\tEXPIRY\tDTE\tNET PREM\tNET ANN%\tPOP%\tSCORE\tQTY\tCAPITAL\tPOS% AFTER\tSECTOR\tSECTOR% AFTER\tHELD")
+		fmt.Fprintln(w, "-\t------\t------\t------\t---\t--------\t--------\t----\t-----\t---\t-------\t----------\t------\t-------------\t----")
+
+		for _, e := range result.Shortlist {
+			c := e.Contract
+			var held []string
+			if e.Candidate.ExistingStockPosition {
+				held = append(held, "stock")
+			}
+			if e.Candidate.ExistingPutPosition {
+				held = append(held, "put")
+			}
+			fmt.Fprintf(w, "%d\t%s\t$%.2f%s\t%s\t%d\t$%.0f\t%.0f%%\t%.0f%%\t%.0f\t%d\t$%.0f\t%.1f%%\t%s\t%.1f%%\t%s\n",
+				e.Rank,
+				c.Symbol,
+				c.Strike,
+				c.Right,
+				c.MaturityDate,
+				c.DTE,
+				c.NetPremium,
+				c.NetAnnualizedReturn,
+				c.POP,
+				c.Score,
+				e.Contracts,
+				e.Capital,
+				e.PositionPercentAfter,
+				truncate(e.Candidate.Sector, 15),
+				e.SectorPercentAfter,
+				strings.Join(held, ", "),
+			)
+		}
+		w.Flush()
+
+		fmt.Println()
+		fmt.Printf("   Total: $%.0f secured for $%.0f net premium\n", result.Capital, result.Premium)
+	}
+
+	fmt.Println()
+	if result.Universe != nil {
+		fmt.Printf("💾 Saved: data/universe.csv, data/solar-system.csv, %s, %s\n", config.OptionsChainCSV, config.ShortlistCSV)
+	} else {
+		fmt.Printf("💾 Saved: data/solar-system.csv, %s, %s\n", config.OptionsChainCSV, config.ShortlistCSV)
+	}
+	if recorder != nil {
+		fmt.Printf("   Snapshot: %s\n", *saveSnapshot)
+	}
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-1] + "…"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"mnmlsm/analysis"
+	"mnmlsm/web"
+)
+
+func main() {
+	// Command line flags
+	positionID := flag.String("position", "", "Position ID to roll (default: every open position)")
+	transactions := flag.String("transactions", "data/options_transactions.csv", "Options transactions CSV file path")
+	minDTE := flag.Int("min-dte", analysis.DefaultRollMinDTE, "Earliest new expiry in days")
+	maxDTE := flag.Int("max-dte", analysis.DefaultRollMaxDTE, "Latest new expiry in days")
+	strikeRange := flag.Float64("strike-range", analysis.DefaultRollStrikeRange, "Reach past the current strike as a fraction of it (0.1 = 10%)")
+	sortBy := flag.String("sort", "credit", "Rank rolls by: credit, breakeven, days or return")
+	top := flag.Int("top", 10, "Rolls to show per position (0 = all)")
+	exchange := flag.String("exchange", "NASDAQ", "Exchange (NASDAQ, NYSE, etc.)")
+	fill := flag.String("fill", analysis.FillMid, "Assumed fill price: mid, bid or mid-minus")
+	fillFraction := flag.Float64("fill-fraction", analysis.DefaultFillFraction, "Share of the spread given up in mid-minus mode")
+	maxSpread := flag.Float64("max-spread", 25, "Maximum bid/ask spread of the new contract as % of mid (0 = no limit)")
+	commissionsFile := flag.String("commissions", "data/commissions.json", "Commission schedule for net credits")
+	snapshot := flag.String("snapshot", "", "Use a saved chain (.json or .csv) instead of the IBKR gateway")
+	workers := flag.Int("workers", analysis.DefaultWorkers, "Concurrent IBKR requests")
+
+	flag.Parse()
+
+	// Find open positions
+	positions := web.CalculateOptionPositions(web.LoadOptionTransactions(*transactions))
+	var open []web.OptionPosition
+	for _, pos := range positions {
+		if pos.Status != "Open" {
+			continue
+		}
+		if *positionID != "" && pos.PositionID != *positionID {
+			continue
+		}
+		open = append(open, pos)
+	}
+
+	if len(open) == 0 {
+		if *positionID != "" {
+			fmt.Fprintf(os.Stderr, "Error: no open position %s\n", *positionID)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: no open option positions\n")
+		}
+		os.Exit(1)
+	}
+
+	sort.Slice(open, func(i, j int) bool {
+		return open[i].Expiry < open[j].Expiry
+	})
+
+	// Create market data provider (IBKR gateway or saved snapshot)
+	provider, err := analysis.OpenProvider(*snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	scanner := analysis.NewScanner(provider)
+
+	commissions := web.LoadCommissionSchedule(*commissionsFile)
+
+	fmt.Printf("🔄 Finding rolls for %d open positions (%d-%d DTE)\n\n", len(open), *minDTE, *maxDTE)
+
+	for _, pos := range open {
+		fmt.Printf("%s %s %s $%.2f exp %s (%d contracts)\n", pos.PositionID, pos.Symbol, pos.OptionType, pos.Strike, pos.Expiry, pos.Contracts)
+
+		roll, err := scanner.FindRolls(analysis.RollParams{
+			Position:         pos,
+			Exchange:         *exchange,
+			MinDTE:           *minDTE,
+			MaxDTE:           *maxDTE,
+			StrikeRange:      *strikeRange,
+			Workers:          *workers,
+			MaxSpreadPercent: *maxSpread,
+			FillMode:         *fill,
+			FillFraction:     *fillFraction,
+			Commissions:      &commissions,
+		})
+		if err != nil {
+			fmt.Printf("   ❌ Error: %v\n\n", err)
+			continue
+		}
+
+		analysis.SortRolls(roll.Candidates, roll.Right, *sortBy)
+		printRolls(roll, *top)
+	}
+}
+
+func printRolls(roll *analysis.RollAnalysis, top int) {
+	fmt.Printf("   Price: $%.2f, %dd left, buy back $%.2f ($%.2f with fees)\n",
+		roll.UnderlyingPrice, roll.CurrentDTE, roll.BuyBackPrice, roll.BuyBackCost)
+
+	if len(roll.Candidates) == 0 {
+		fmt.Printf("   No roll candidates found\n\n")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "   STRIKE\tEXPIRY\tDTE\t+DAYS\tPREMIUM\tFEES\tNET CREDIT\tBREAKEVEN\tANN%\tPOP")
+	fmt.Fprintln(w, "   "+strings.Repeat("-", 90))
+
+	for i, c := range roll.Candidates {
+		if top > 0 && i >= top {
+			break
+		}
+
+		expiryDate, _ := time.Parse("20060102", c.New.MaturityDate)
+
+		fmt.Fprintf(w, "   $%.2f\t%s\t%dd\t+%d\t$%.0f\t$%.2f\t%s\t$%.2f\t%.0f%%\t%.1f%%\n",
+			c.New.Strike,
+			expiryDate.Format("Jan 02"),
+			c.New.DTE,
+			c.AddedDays,
+			c.NewPremium,
+			c.Commission,
+			formatSigned(c.NetCredit),
+			c.Breakeven,
+			c.AnnualizedReturn,
+			c.New.POP,
+		)
+	}
+
+	w.Flush()
+	fmt.Println()
+}
+
+// formatSigned formats a credit (+) or debit (-) in dollars
+func formatSigned(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.0f", -amount)
+	}
+	return fmt.Sprintf("+$%.0f", amount)
+}
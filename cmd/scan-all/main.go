@@ -4,47 +4,186 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"mnmlsm/analysis"
-	"mnmlsm/ibkr"
+	"mnmlsm/web"
 )
 
 func main() {
 	// Command line flags
+	mode := flag.String("mode", "solar-system", "Scan mode: solar-system (stocks in -input) or covered-calls (stocks we hold)")
 	right := flag.String("right", "P", "Option type: C for calls, P for puts")
 	minReturn := flag.Float64("min-return", 100, "Minimum annualized return percentage")
-	strikeRange := flag.Float64("strike-range", 5.0, "Strike range around current price in dollars (e.g., 5.0 = $5)")
-	numExpiries := flag.Int("expiries", 2, "Number of Friday expiries to scan")
-	output := flag.String("output", "data/options-chain.csv", "Output CSV file path")
+	strikeMode := flag.String("strike-mode", analysis.StrikeModeDollar, "Strike selection: dollar, percent, otm or delta")
+	strikeRange := flag.Float64("strike-range", 0, "Strike range: dollars in dollar mode (5 = $5), fraction of price otherwise (0.1 = 10%); 0 = mode default ($5, 10%, all OTM, 30%)")
+	minDelta := flag.Float64("min-delta", 0.15, "Minimum |delta| in delta mode")
+	maxDelta := flag.Float64("max-delta", 0.30, "Maximum |delta| in delta mode")
+	minOI := flag.Int("min-oi", 0, "Minimum open interest (0 = no minimum)")
+	minVolume := flag.Int("min-volume", 0, "Minimum contracts traded today (0 = no minimum)")
+	maxSpread := flag.Float64("max-spread", 25, "Maximum bid/ask spread as % of mid (0 = no limit)")
+	fill := flag.String("fill", analysis.FillMid, "Assumed fill price: mid, bid or mid-minus")
+	fillFraction := flag.Float64("fill-fraction", analysis.DefaultFillFraction, "Share of the spread given up in mid-minus mode")
+	numExpiries := flag.Int("expiries", 2, "Number of option months to scan")
+	maxDTE := flag.Int("max-dte", 0, "Maximum days to expiration (0 = no limit)")
+	exchange := flag.String("exchange", "NASDAQ", "Exchange (NASDAQ, NYSE, etc.)")
+	workers := flag.Int("workers", analysis.DefaultWorkers, "Concurrent symbols and IBKR requests")
+	output := flag.String("output", "", "Results file (default data/options-chain.csv, or data/covered-calls.csv in covered-calls mode); - streams to stdout")
+	outputFormat := flag.String("output-format", "", "Results format: "+strings.Join(analysis.ResultFormats, ", ")+" (default from the -output extension; ndjson for -)")
 	solarSystem := flag.String("input", "data/solar-system.csv", "Input solar-system.csv file path")
+	stockTransactions := flag.String("stocks", "data/stocks_transactions.csv", "Stock transactions CSV for covered-calls mode")
+	ccMinReturn := flag.Float64("cc-min-return", analysis.DefaultCoveredCallMinReturn, "Covered calls: minimum annualized % on cost basis when above water")
+	ccMinReturnUnderwater := flag.Float64("cc-min-return-underwater", analysis.DefaultCoveredCallMinReturnUnderwater, "Covered calls: minimum annualized % on cost basis when underwater")
+	snapshot := flag.String("snapshot", "", "Scan a saved chain (.json or .csv) instead of the IBKR gateway")
+	saveSnapshot := flag.String("save-snapshot", "", "Save the scanned chain to this file (.json or .csv)")
+	explain := flag.Bool("explain", false, "Print each symbol's funnel (strikes → contracts → priced → passed) and why strikes and contracts were rejected")
+	historyDir := flag.String("history", analysis.DefaultScanHistoryDir, "Directory to save the run to for scan-diff, except from a -snapshot (empty = don't save)")
+	sortBy := flag.String("sort", "score", "Rank contracts by: score, efficiency, net, return, pop, touch, iv-hv or breakeven")
+	scorePreset := flag.String("score", analysis.DefaultScorePreset, "Score preset: "+strings.Join(analysis.ScorePresetNames(), ", "))
+	scoreWeights := flag.String("weights", "", "Score weight overrides, e.g. return=4,liquidity=0 (components: "+strings.Join(analysis.ScoreComponents, ", ")+")")
+	eventsFile := flag.String("events", "data/events.csv", "Event calendar CSV")
+	ivHistory := flag.String("iv-history", analysis.DefaultIVHistoryCSV, "ATM IV history for IV rank; today's ATM IVs are recorded to it, except from a -snapshot (empty = neither)")
+	volSurfaces := flag.String("vol-surfaces", analysis.DefaultVolSurfacesJSON, "File each symbol's smile and term structure are saved to for the volatility page, except from a -snapshot (empty = don't save)")
+	earnings := flag.String("earnings", analysis.EarningsExclude, "Contracts spanning earnings: exclude, flag or ignore")
+	commissionsFile := flag.String("commissions", "data/commissions.json", "Commission schedule for net returns")
+	rulesFile := flag.String("rules", "data/rules.json", "Rules config for position sizing and the diversification score (risk per trade, position and sector max, cash reserve)")
+	riskModel := flag.String("risk-model", analysis.RiskSigma, "Risk per contract for sizing: sigma, stop or max")
+	riskSigmas := flag.Float64("risk-sigmas", analysis.DefaultRiskSigmas, "Adverse move in standard deviations for the sigma risk model")
+	riskStop := flag.Float64("risk-stop", analysis.DefaultRiskStopPercent, "Adverse move in % of the stock price for the stop risk model")
 
 	flag.Parse()
 
+	if *mode != "solar-system" && *mode != "covered-calls" {
+		fmt.Fprintf(os.Stderr, "Error: --mode must be 'solar-system' or 'covered-calls'\n")
+		os.Exit(1)
+	}
+
+	if *output == "" {
+		*output = "data/options-chain.csv"
+		if *mode == "covered-calls" {
+			*output = "data/covered-calls.csv"
+		}
+	}
+
+	// Streamed results get stdout to themselves; progress goes to stderr
+	if *output == "-" {
+		os.Stdout = os.Stderr
+	}
+
 	// Validate right parameter
 	if *right != "P" && *right != "C" {
 		fmt.Fprintf(os.Stderr, "Error: --right must be 'P' or 'C'\n")
 		os.Exit(1)
 	}
 
-	// Create IBKR client
-	client := ibkr.NewClient()
+	weights, err := analysis.ParseScoreWeights(*scorePreset, *scoreWeights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Create market data provider (IBKR gateway or saved snapshot)
+	provider, err := analysis.OpenProvider(*snapshot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Record the chain while scanning if requested
+	var recorder *analysis.RecordingProvider
+	if *saveSnapshot != "" {
+		recorder = analysis.NewRecordingProvider(provider)
+		provider = recorder
+	}
 
 	// Create scanner
-	scanner := analysis.NewScanner(client)
+	scanner := analysis.NewScanner(provider)
 
 	// Setup batch scan parameters
 	params := analysis.BatchScanParams{
 		SolarSystemCSV: *solarSystem,
-		OutputCSV:      *output,
+		Output:         *output,
+		OutputFormat:   *outputFormat,
+		Exchange:       *exchange,
 		Right:          *right,
 		MinReturn:      *minReturn,
+		StrikeMode:     *strikeMode,
 		StrikeRange:    *strikeRange,
+		MinDelta:       *minDelta,
+		MaxDelta:       *maxDelta,
 		NumExpiries:    *numExpiries,
+		MaxDTE:         *maxDTE,
+		Workers:        *workers,
+		SortBy:         *sortBy,
+		HistoryDir:     *historyDir,
+		Explain:        *explain,
+
+		MinOpenInterest:  *minOI,
+		MinVolume:        *minVolume,
+		MaxSpreadPercent: *maxSpread,
+		FillMode:         *fill,
+		FillFraction:     *fillFraction,
+	}
+
+	// Commission schedule for net-of-fee returns
+	commissions := web.LoadCommissionSchedule(*commissionsFile)
+	params.Commissions = &commissions
+
+	// Event calendar for the earnings check
+	params.Events = web.LoadEvents(*eventsFile)
+	params.EarningsMode = *earnings
+
+	// IV history for IV rank, recorded to after the scan
+	if *ivHistory != "" {
+		params.IVHistory = web.LoadIVHistory(*ivHistory)
+		params.IVHistoryCSV = *ivHistory
+	}
+	params.VolSurfacesJSON = *volSurfaces
+
+	rules := web.LoadTradingRules(*rulesFile)
+	portfolio, err := analysis.LoadPortfolioSnapshot(rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not sizing positions or scoring diversification: %v\n", err)
+		portfolio = nil
+	}
+
+	// Position sizing against the portfolio (new positions only)
+	if *mode == "solar-system" && portfolio != nil {
+		sizing := analysis.NewSizingParams(portfolio, rules, *riskModel)
+		sizing.Sigmas = *riskSigmas
+		sizing.StopPercent = *riskStop
+		params.Sizing = &sizing
+	}
+
+	// Ranking score (covered calls are ranked on cost basis instead)
+	if *mode == "solar-system" {
+		params.Scoring = &analysis.Scorer{
+			Weights: weights,
+			Context: analysis.NewScoreContext(portfolio, rules, params.Events),
+		}
 	}
 
 	// Run batch scan
-	if err := scanner.ScanAllStocks(params); err != nil {
+	if *mode == "covered-calls" {
+		err = scanner.ScanCoveredCalls(analysis.CoveredCallParams{
+			BatchScanParams:      params,
+			StockTransactionsCSV: *stockTransactions,
+			MinReturn:            *ccMinReturn,
+			MinReturnUnderwater:  *ccMinReturnUnderwater,
+		})
+	} else {
+		err = scanner.ScanAllStocks(params)
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+
+	if recorder != nil {
+		if err := analysis.SaveSnapshot(*saveSnapshot, recorder.Recorded()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("   Snapshot: %s\n", *saveSnapshot)
+	}
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"mnmlsm/analysis"
+)
+
+func main() {
+	historyDir := flag.String("history", analysis.DefaultScanHistoryDir, "Scan history directory")
+	kind := flag.String("kind", analysis.ScanKindSolarSystem, "Runs to compare: solar-system, covered-calls or symbol-<SYMBOL> (empty = any)")
+	from := flag.String("from", "previous", "Older run: ID, ID prefix, latest or previous")
+	to := flag.String("to", "latest", "Newer run: ID, ID prefix, latest or previous")
+	list := flag.Bool("list", false, "List saved runs instead of diffing")
+	minChange := flag.Float64("min-change", 0, "Only show premium changes of at least this many dollars per contract")
+	format := flag.String("format", "table", "Output format: table or json")
+
+	flag.Parse()
+
+	if *list {
+		listRuns(*historyDir, *kind)
+		return
+	}
+
+	fromRun, err := analysis.LoadScanRun(*historyDir, *kind, *from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	toRun, err := analysis.LoadScanRun(*historyDir, *kind, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	diff, err := analysis.DiffScanRuns(fromRun, toRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Drop small premium moves
+	var changed []analysis.ContractChange
+	for _, c := range diff.Changed {
+		if math.Abs(c.Change) >= *minChange {
+			changed = append(changed, c)
+		}
+	}
+	diff.Changed = changed
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printDiff(diff)
+}
+
+func listRuns(dir, kind string) {
+	runs, err := analysis.ListScanRuns(dir, kind)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No scan runs in %s\n", dir)
+		return
+	}
+
+	fmt.Printf("🗂️  %d scan runs in %s:\n\n", len(runs), dir)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tKIND\tRIGHT\tSYMBOLS\tCONTRACTS")
+	fmt.Fprintln(w, "--\t----\t----\t-----\t-------\t---------")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n",
+			r.ID, r.Time.Local().Format("2006-01-02 15:04"), r.Kind, r.Right, r.Symbols, r.Contracts)
+	}
+	w.Flush()
+}
+
+func printDiff(diff analysis.ScanDiff) {
+	fmt.Printf("🔀 Scan diff: %s (%d contracts) → %s (%d contracts)\n\n",
+		diff.From.ID, diff.From.Contracts, diff.To.ID, diff.To.Contracts)
+
+	printContracts("🆕 Entered", diff.Entered)
+	printContracts("📉 Dropped out", diff.Dropped)
+	printContracts("⌛ Expired", diff.Expired)
+
+	fmt.Printf("💲 Premium changes (%d):\n", len(diff.Changed))
+	if len(diff.Changed) == 0 {
+		fmt.Println("   none")
+		return
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSTRIKE\tEXPIRY\tSTOCK\tPREMIUM\tCHANGE\tCHANGE%\tNET ANN%")
+	fmt.Fprintln(w, "------\t------\t------\t-----\t-------\t------\t-------\t--------")
+	for _, c := range diff.Changed {
+		fmt.Fprintf(w, "%s\t$%.2f%s\t%s\t$%.2f → $%.2f\t$%.0f → $%.0f\t%+.0f\t%+.1f%%\t%.0f%% → %.0f%%\n",
+			c.Symbol, c.Strike, c.Right, c.MaturityDate,
+			c.OldPrice, c.NewPrice,
+			c.OldPremium, c.NewPremium,
+			c.Change, c.ChangePercent,
+			c.OldReturn, c.NewReturn,
+		)
+	}
+	w.Flush()
+}
+
+func printContracts(title string, contracts []analysis.OptionContract) {
+	fmt.Printf("%s (%d):\n", title, len(contracts))
+	if len(contracts) == 0 {
+		fmt.Println("   none")
+		fmt.Println()
+		return
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tSTRIKE\tEXPIRY\tDTE\tSTOCK\tPREMIUM\tNET ANN%\tPOP%")
+	fmt.Fprintln(w, "------\t------\t------\t---\t-----\t-------\t--------\t----")
+	for _, c := range contracts {
+		fmt.Fprintf(w, "%s\t$%.2f%s\t%s\t%d\t$%.2f\t$%.0f\t%.0f%%\t%.0f%%\n",
+			c.Symbol, c.Strike, c.Right, c.MaturityDate, c.DTE, c.UnderlyingPrice, c.Premium, c.NetAnnualizedReturn, c.POP)
+	}
+	w.Flush()
+	fmt.Println()
+}
@@ -215,6 +215,44 @@ func main() {
 	}
 	fmt.Println()
 
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("                 OPTION COVERAGE")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	coverage := web.CalculateCoverage(transactions, stockTransactions, web.LoadOptionTransactions("data/options_transactions.csv"))
+	current := coverage.Current
+
+	callStatus := "✓ All Covered"
+	if len(current.UncoveredCalls) > 0 {
+		callStatus = fmt.Sprintf("✗ %d Uncovered", current.UncoveredContracts())
+	}
+	fmt.Printf("Covered Calls:             %s\n", callStatus)
+	for _, c := range current.UncoveredCalls {
+		fmt.Printf("   %-6s %d of %d contracts uncovered (%.0f shares)\n", c.Symbol, c.Uncovered, c.Contracts, c.Shares)
+	}
+
+	putStatus := "✓ Cash Secured"
+	if current.PutShortfall > 0 {
+		putStatus = fmt.Sprintf("✗ Short %s", web.FormatCurrency(current.PutShortfall))
+	}
+	fmt.Printf("Cash-Secured Puts:         %s (%s notional, %s cash)\n",
+		putStatus,
+		web.FormatCurrency(current.PutNotional),
+		web.FormatCurrency(current.Cash))
+
+	if len(coverage.Issues) > 0 {
+		fmt.Printf("\nPast violations (%d of %d days):\n", len(coverage.Issues), coverage.DaysChecked)
+		for _, day := range coverage.Issues {
+			fmt.Printf("   %s  uncovered calls: %d, put shortfall: %s\n",
+				day.Date,
+				day.UncoveredContracts(),
+				web.FormatCurrency(day.PutShortfall))
+		}
+	} else {
+		fmt.Printf("No violations in %d days\n", coverage.DaysChecked)
+	}
+	fmt.Println()
+
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 }
 
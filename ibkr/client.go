@@ -11,6 +11,9 @@ import (
 	"time"
 )
 
+// requestInterval spaces out HTTP requests to the gateway: 50 req/s, under its 60 req/s limit
+const requestInterval = 20 * time.Millisecond
+
 // Client represents an IBKR API client
 type Client struct {
 	httpClient *http.Client
@@ -27,13 +30,26 @@ func NewClient() *Client {
 
 	return &Client{
 		httpClient: &http.Client{
-			Transport: tr,
+			Transport: &throttledTransport{next: tr, throttle: time.Tick(requestInterval)},
 			Timeout:   30 * time.Second,
 		},
 		baseURL: "https://localhost:5001/v1/api",
 	}
 }
 
+// throttledTransport holds every request, preflights included, to the shared rate limit, however
+// many goroutines use the client
+type throttledTransport struct {
+	next     http.RoundTripper
+	throttle <-chan time.Time
+}
+
+// RoundTrip waits for the next tick before sending the request
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-t.throttle
+	return t.next.RoundTrip(req)
+}
+
 // SearchSymbol searches for a symbol and returns its ConID
 func (c *Client) SearchSymbol(symbol string) (int, error) {
 	url := fmt.Sprintf("%s/iserver/secdef/search?symbol=%s", c.baseURL, symbol)
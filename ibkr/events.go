@@ -0,0 +1,75 @@
+package ibkr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Market data snapshot fields with a security's upcoming corporate events
+const (
+	FieldUpcomingEarnings = "7686" // Next earnings release
+	FieldDividendExDate   = "7288" // Next ex-dividend date
+)
+
+// UpcomingEvents is the next earnings release and ex-dividend date of a security, as reported
+// by the gateway (empty = none announced)
+type UpcomingEvents struct {
+	ConID      int
+	Earnings   string
+	ExDividend string
+}
+
+// GetUpcomingEvents fetches the next earnings release and ex-dividend date of a security
+func (c *Client) GetUpcomingEvents(conid int) (*UpcomingEvents, error) {
+	url := fmt.Sprintf("%s/iserver/marketdata/snapshot?conids=%d&fields=%s,%s",
+		c.baseURL, conid, FieldUpcomingEarnings, FieldDividendExDate)
+
+	// Preflight request to initialize
+	c.httpClient.Get(url)
+	time.Sleep(1 * time.Second)
+
+	// Actual request
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var data []map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing market data: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no event data returned for conid %d", conid)
+	}
+
+	return &UpcomingEvents{
+		ConID:      conid,
+		Earnings:   parseFieldString(data[0][FieldUpcomingEarnings]),
+		ExDividend: parseFieldString(data[0][FieldDividendExDate]),
+	}, nil
+}
+
+// parseFieldString extracts a text field value, in map ({"v": value}) or direct format
+func parseFieldString(field interface{}) string {
+	switch val := field.(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case float64:
+		return fmt.Sprintf("%.0f", val)
+	case map[string]interface{}:
+		if v, ok := val["v"]; ok {
+			return parseFieldString(v)
+		}
+	}
+	return ""
+}
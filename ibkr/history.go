@@ -0,0 +1,47 @@
+package ibkr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// GetDailyBars fetches daily bars for a security over a period (e.g., "3m", "1y"), oldest first
+func (c *Client) GetDailyBars(conid int, period string) ([]Bar, error) {
+	url := fmt.Sprintf("%s/iserver/marketdata/history?conid=%d&period=%s&bar=1d&outsideRth=false",
+		c.baseURL, conid, period)
+
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	var history HistoryResponse
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, fmt.Errorf("parsing history: %w", err)
+	}
+
+	bars := make([]Bar, 0, len(history.Data))
+	for _, d := range history.Data {
+		bars = append(bars, Bar{
+			Date:   time.UnixMilli(d.Time).UTC().Format("2006-01-02"),
+			Open:   d.Open,
+			High:   d.High,
+			Low:    d.Low,
+			Close:  d.Close,
+			Volume: d.Volume,
+		})
+	}
+
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no history returned for conid %d", conid)
+	}
+	return bars, nil
+}
@@ -55,6 +55,30 @@ func (c *Client) SearchUnderlying(symbol, exchange string) (int, []string, error
 // GetStrikes fetches available strikes for a given option month
 // strikeRange limits results to strikes within +/- strikeRange of currentPrice
 func (c *Client) GetStrikes(conid int, month string, currentPrice, strikeRange float64) ([]float64, error) {
+	strikes, err := c.GetAllStrikes(conid, month)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter strikes within range if specified
+	if strikeRange > 0 {
+		minStrike := currentPrice - strikeRange
+		maxStrike := currentPrice + strikeRange
+
+		filtered := make([]float64, 0)
+		for _, strike := range strikes.Put {
+			if strike >= minStrike && strike <= maxStrike {
+				filtered = append(filtered, strike)
+			}
+		}
+		return filtered, nil
+	}
+
+	return strikes.Put, nil
+}
+
+// GetAllStrikes fetches the unfiltered call and put strikes for a given option month
+func (c *Client) GetAllStrikes(conid int, month string) (*StrikesResponse, error) {
 	url := fmt.Sprintf("%s/iserver/secdef/strikes?conid=%d&sectype=OPT&month=%s",
 		c.baseURL, conid, month)
 
@@ -74,21 +98,7 @@ func (c *Client) GetStrikes(conid int, month string, currentPrice, strikeRange f
 		return nil, fmt.Errorf("parsing strikes: %w", err)
 	}
 
-	// Filter strikes within range if specified
-	if strikeRange > 0 {
-		minStrike := currentPrice - strikeRange
-		maxStrike := currentPrice + strikeRange
-
-		filtered := make([]float64, 0)
-		for _, strike := range strikes.Put {
-			if strike >= minStrike && strike <= maxStrike {
-				filtered = append(filtered, strike)
-			}
-		}
-		return filtered, nil
-	}
-
-	return strikes.Put, nil
+	return &strikes, nil
 }
 
 // GetContractInfo fetches detailed contract information for a specific option
@@ -120,7 +130,8 @@ func (c *Client) GetOptionPricing(conid int) (*OptionPricing, error) {
 	// Request fields:
 	// 84 = Bid, 86 = Ask (NOT 85!), 88 = Ask Size
 	// 31 = Last, 7283 = Implied Vol, 7308 = Delta
-	fields := "31,84,86,88,7283,7308"
+	// 7638 = Option Open Interest, 7762 = Volume (unformatted)
+	fields := "31,84,86,88,7283,7308,7638,7762"
 	url := fmt.Sprintf("%s/iserver/marketdata/snapshot?conids=%d&fields=%s",
 		c.baseURL, conid, fields)
 
@@ -157,6 +168,8 @@ func (c *Client) GetOptionPricing(conid int) (*OptionPricing, error) {
 	pricing.LastPrice = parseOptionPrice(item["31"])
 	pricing.ImpliedVol = parseOptionPrice(item["7283"])
 	pricing.Delta = parseOptionPrice(item["7308"])
+	pricing.OpenInterest = int(parseFieldValue(item["7638"]))
+	pricing.Volume = int(parseFieldValue(item["7762"]))
 
 	return pricing, nil
 }
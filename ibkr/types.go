@@ -77,4 +77,29 @@ type OptionPricing struct {
 	Vega            float64
 	ImpliedVol      float64
 	UnderlyingPrice float64
+	OpenInterest    int // Contracts open at the previous close
+	Volume          int // Contracts traded today
+}
+
+// Bar is one daily OHLC price bar
+type Bar struct {
+	Date   string  `json:"date"` // 2006-01-02
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// HistoryResponse represents the historical market data response
+type HistoryResponse struct {
+	Symbol string `json:"symbol"`
+	Data   []struct {
+		Open   float64 `json:"o"`
+		High   float64 `json:"h"`
+		Low    float64 `json:"l"`
+		Close  float64 `json:"c"`
+		Volume float64 `json:"v"`
+		Time   int64   `json:"t"` // Unix milliseconds
+	} `json:"data"`
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"mnmlsm/api"
 	"mnmlsm/web"
 	"net/http"
 )
@@ -22,6 +23,11 @@ func main() {
 	mux.HandleFunc("/risk", web.HandleRisk)
 	mux.HandleFunc("/rules", web.HandleRules)
 
+	// API
+	mux.HandleFunc("/api/check-trade", api.HandleCheckTrade)
+	mux.HandleFunc("/api/scans", api.HandleScans)
+	mux.HandleFunc("/api/scans/diff", api.HandleScanDiff)
+
 	log.Println("Server starting on http://localhost:8080")
 	if err := http.ListenAndServe(":8080", mux); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"os"
+)
+
+// CommissionSchedule is a broker commission plan plus regulatory and clearing fees for options
+type CommissionSchedule struct {
+	Plan string `json:"plan"` // "fixed" or "tiered"
+
+	// Fixed plan: per-contract rate that steps down for cheap options
+	FixedPerContract  float64          `json:"fixedPerContract"`
+	FixedPremiumRates []PremiumRate    `json:"fixedPremiumRates"` // Lower rates below a premium per share
+	FixedMinimum      float64          `json:"fixedMinimum"`      // Minimum per order
+	TieredRates       []CommissionTier `json:"tieredRates"`       // Tiered plan, by monthly contract volume
+	TieredMinimum     float64          `json:"tieredMinimum"`     // Minimum per order
+	MonthlyContracts  int              `json:"monthlyContracts"`  // Contracts traded this month, picks the tier
+	Fees              RegulatoryFees   `json:"fees"`
+}
+
+// PremiumRate is a per-contract commission that applies below a premium per share
+type PremiumRate struct {
+	BelowPremium float64 `json:"belowPremium"`
+	PerContract  float64 `json:"perContract"`
+}
+
+// CommissionTier is a per-contract rate up to a monthly contract volume (0 = no upper bound)
+type CommissionTier struct {
+	UpToContracts int     `json:"upToContracts"`
+	PerContract   float64 `json:"perContract"`
+}
+
+// RegulatoryFees are the exchange, clearing and regulatory fees charged on top of commission
+type RegulatoryFees struct {
+	ORFPerContract float64 `json:"orfPerContract"` // Options Regulatory Fee, buys and sells
+	OCCPerContract float64 `json:"occPerContract"` // OCC clearing fee, buys and sells
+	OCCMaxPerOrder float64 `json:"occMaxPerOrder"` // OCC clearing fee cap per order (0 = no cap)
+	TAFPerContract float64 `json:"tafPerContract"` // FINRA Trading Activity Fee, sells only
+	TAFMaxPerOrder float64 `json:"tafMaxPerOrder"` // FINRA TAF cap per order (0 = no cap)
+	SECRate        float64 `json:"secRate"`        // SEC transaction fee as a fraction of sale proceeds
+}
+
+// DefaultCommissionSchedule returns IBKR Pro fixed pricing for US options
+func DefaultCommissionSchedule() CommissionSchedule {
+	return CommissionSchedule{
+		Plan:             "fixed",
+		FixedPerContract: 0.65,
+		FixedPremiumRates: []PremiumRate{
+			{BelowPremium: 0.05, PerContract: 0.25},
+			{BelowPremium: 0.10, PerContract: 0.50},
+		},
+		FixedMinimum: 1.00,
+		TieredRates: []CommissionTier{
+			{UpToContracts: 10000, PerContract: 0.65},
+			{UpToContracts: 50000, PerContract: 0.50},
+			{UpToContracts: 100000, PerContract: 0.25},
+			{UpToContracts: 0, PerContract: 0.15},
+		},
+		TieredMinimum: 1.00,
+		Fees: RegulatoryFees{
+			ORFPerContract: 0.02295,
+			OCCPerContract: 0.02,
+			OCCMaxPerOrder: 55.00,
+			TAFPerContract: 0.00279,
+			TAFMaxPerOrder: 8.30,
+			SECRate:        0.0000278,
+		},
+	}
+}
+
+// LoadCommissionSchedule reads a commission schedule from JSON, falling back to the default schedule
+func LoadCommissionSchedule(filename string) CommissionSchedule {
+	schedule := DefaultCommissionSchedule()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error opening commission schedule: %v", err)
+		}
+		return schedule
+	}
+
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		log.Printf("Error parsing commission schedule: %v", err)
+		return DefaultCommissionSchedule()
+	}
+
+	return schedule
+}
+
+// OptionCommission returns the total commission and fees for one options order.
+// premium is the price per share; sell is true for sales (sell to open / sell to close).
+func (s CommissionSchedule) OptionCommission(contracts int, premium float64, sell bool) float64 {
+	if contracts <= 0 {
+		return 0
+	}
+	n := float64(contracts)
+
+	// Broker commission
+	var commission float64
+	if s.Plan == "tiered" {
+		commission = math.Max(s.TieredMinimum, n*s.tieredRate())
+	} else {
+		commission = math.Max(s.FixedMinimum, n*s.fixedRate(premium))
+	}
+
+	// Regulatory and clearing fees
+	fees := n * s.Fees.ORFPerContract
+	fees += capFee(n*s.Fees.OCCPerContract, s.Fees.OCCMaxPerOrder)
+	if sell {
+		fees += capFee(n*s.Fees.TAFPerContract, s.Fees.TAFMaxPerOrder)
+		fees += premium * 100 * n * s.Fees.SECRate
+	}
+
+	return math.Round((commission+fees)*100) / 100
+}
+
+// fixedRate returns the fixed-plan per-contract rate for an option premium
+func (s CommissionSchedule) fixedRate(premium float64) float64 {
+	rate := s.FixedPerContract
+	best := math.Inf(1)
+	for _, r := range s.FixedPremiumRates {
+		if premium < r.BelowPremium && r.BelowPremium < best {
+			rate = r.PerContract
+			best = r.BelowPremium
+		}
+	}
+	return rate
+}
+
+// tieredRate returns the tiered-plan per-contract rate for this month's contract volume
+func (s CommissionSchedule) tieredRate() float64 {
+	for _, tier := range s.TieredRates {
+		if tier.UpToContracts == 0 || s.MonthlyContracts < tier.UpToContracts {
+			return tier.PerContract
+		}
+	}
+	return s.FixedPerContract
+}
+
+func capFee(fee, max float64) float64 {
+	if max > 0 {
+		return math.Min(fee, max)
+	}
+	return fee
+}
@@ -0,0 +1,338 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Compliance statuses (match the risk page)
+const (
+	ComplianceCompliant = "compliant"
+	ComplianceWarning   = "warning"
+	ComplianceViolation = "violation"
+	ComplianceManual    = "manual"   // Not checked automatically
+	ComplianceDisabled  = "disabled" // Rule disabled in data/rules.json
+)
+
+// complianceWarnFraction is the share of a limit past which a rule warns (18% of a 20% sector cap)
+const complianceWarnFraction = 0.9
+
+// RuleCompliance is the live status of one trading rule
+type RuleCompliance struct {
+	Number    int
+	Rule      Rule
+	Status    string
+	Summary   string
+	Offenders []ComplianceOffender
+}
+
+// ComplianceOffender is a position or account breaking a rule, and since when
+type ComplianceOffender struct {
+	Name     string
+	Detail   string
+	Since    string // 2006-01-02
+	DaysOpen int
+}
+
+// capitalItem is an open position's capital and open date, for dating violations
+type capitalItem struct {
+	Symbol string
+	Date   string
+	Amount float64
+}
+
+// CalculateRulesCompliance evaluates each rule against the current portfolio
+func CalculateRulesCompliance(rules TradingRules, analytics Analytics, vix float64) []RuleCompliance {
+	stockTransactions := LoadStockTransactions("data/stocks_transactions.csv")
+	stockPrices := LoadStockPrices("data/universe.csv")
+	stockPositions := CalculateAllPositions(stockTransactions, stockPrices)
+	optionTransactions := LoadOptionTransactions("data/options_transactions.csv")
+	optionPositions := CalculateOptionPositions(optionTransactions)
+
+	cash := CalculateCashPosition(analytics)
+	netWorth := cash.ActiveCapital + cash.DryPowder + cash.WiseBalance
+
+	// Open stock and option positions
+	stocks := make(map[string]Position)
+	var puts, calls []OptionPosition
+	var items []capitalItem
+	for _, pos := range stockPositions {
+		if pos.Type == "open" {
+			stocks[pos.Symbol] = pos
+			items = append(items, capitalItem{Symbol: pos.Symbol, Date: pos.OpenDate, Amount: pos.CostBasis})
+		}
+	}
+	for _, pos := range optionPositions {
+		if pos.Status != "Open" {
+			continue
+		}
+		if pos.OptionType == "Put" {
+			puts = append(puts, pos)
+			items = append(items, capitalItem{Symbol: pos.Symbol, Date: pos.OpenDate, Amount: pos.Capital})
+		} else {
+			calls = append(calls, pos)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Date < items[j].Date
+	})
+
+	var report []RuleCompliance
+	for i, rule := range rules.Rules {
+		rc := RuleCompliance{Number: i + 1, Rule: rule, Status: ComplianceCompliant}
+
+		if !rule.Enabled {
+			rc.Status = ComplianceDisabled
+			report = append(report, rc)
+			continue
+		}
+
+		switch rule.ID {
+		case RuleNoMargin:
+			rc.Summary = fmt.Sprintf("Dry powder %s", FormatCurrency(cash.DryPowder))
+			if cash.DryPowder < 0 {
+				rc.addOffender("Cash", fmt.Sprintf("%s below zero", FormatCurrency(-cash.DryPowder)), violationSince(items, -cash.DryPowder))
+			}
+
+		case RuleCashSecuredOnly:
+			rc.Summary = fmt.Sprintf("%d open calls, %d open puts", len(calls), len(puts))
+			written := make(map[string]int)
+			latest := make(map[string]string)
+			for _, c := range calls {
+				written[c.Symbol] += c.Contracts
+				if c.OpenDate > latest[c.Symbol] {
+					latest[c.Symbol] = c.OpenDate
+				}
+			}
+			for symbol, contracts := range written {
+				covered := int(math.Floor(stocks[symbol].Shares / 100))
+				if contracts > covered {
+					rc.addOffender(symbol, fmt.Sprintf("%d calls written, %.0f shares cover %d", contracts, stocks[symbol].Shares, covered), latest[symbol])
+				}
+			}
+
+		case RuleNoEarnings:
+			rc.Summary = "Open options spanning earnings"
+			for _, pos := range append(append([]OptionPosition{}, puts...), calls...) {
+				if pos.SpansEarnings() {
+					rc.addOffender(optionLabel(pos), pos.FormatEvents(), pos.OpenDate)
+				}
+			}
+
+		case RuleDocumentRationale:
+			rc.Summary = "Notes on every option trade"
+			for _, tx := range optionTransactions {
+				if tx.Action == "Sell to Open" && strings.TrimSpace(tx.Notes) == "" {
+					rc.addOffender(fmt.Sprintf("%s %s $%.2f", tx.Symbol, tx.OptionType, tx.Strike), fmt.Sprintf("%s %s has no notes", tx.PositionID, tx.Action), tx.Date)
+				}
+			}
+
+		case RuleCashMinimum:
+			reserve := netWorth * rule.Threshold / 100
+			rc.Summary = fmt.Sprintf("Cash %s = %.1f%% of net worth (min %s%%)", FormatCurrency(cash.DryPowder), percentOf(cash.DryPowder, netWorth), formatThreshold(rule.Threshold))
+			if cash.DryPowder < reserve {
+				rc.addOffender("Cash", fmt.Sprintf("%s short of the %s minimum", FormatCurrency(reserve-cash.DryPowder), FormatCurrency(reserve)), violationSince(items, reserve-cash.DryPowder))
+			} else if cash.DryPowder < reserve/complianceWarnFraction {
+				rc.warn()
+			}
+
+		case RuleMaxPosition:
+			limit := netWorth * rule.Threshold / 100
+			bySymbol := make(map[string]float64)
+			for _, item := range items {
+				bySymbol[item.Symbol] += item.Amount
+			}
+			largest := ""
+			for symbol, amount := range bySymbol {
+				if largest == "" || amount > bySymbol[largest] {
+					largest = symbol
+				}
+				if amount > limit {
+					rc.addOffender(symbol, fmt.Sprintf("%s = %.1f%% of net worth", FormatCurrency(amount), percentOf(amount, netWorth)), violationSince(itemsWhere(items, func(s string) bool { return s == symbol }), amount-limit))
+				} else if amount > limit*complianceWarnFraction {
+					rc.warn()
+				}
+			}
+			rc.Summary = fmt.Sprintf("Max %s%% = %s per position", formatThreshold(rule.Threshold), FormatCurrency(limit))
+			if largest != "" {
+				rc.Summary += fmt.Sprintf("; largest %s at %.1f%%", largest, percentOf(bySymbol[largest], netWorth))
+			}
+
+		case RuleMaxSector:
+			limit := netWorth * rule.Threshold / 100
+			sectors := LoadSectorMapping("data/universe.csv")
+			rc.Summary = fmt.Sprintf("Max %s%% = %s per sector", formatThreshold(rule.Threshold), FormatCurrency(limit))
+			for _, exposure := range CalculateSectorExposure() {
+				if exposure.Amount > limit {
+					sector := exposure.Sector
+					rc.addOffender(sector, fmt.Sprintf("%s = %.1f%% of net worth", FormatCurrency(exposure.Amount), percentOf(exposure.Amount, netWorth)), violationSince(itemsWhere(items, func(s string) bool { return sectors[s] == sector }), exposure.Amount-limit))
+				} else if exposure.Amount > limit*complianceWarnFraction {
+					rc.warn()
+				}
+			}
+
+		case RuleMaxRisk:
+			rc.Status = ComplianceManual
+			rc.Summary = "Checked per trade by check-trade and scan position sizing"
+
+		case RuleMonthlyTarget:
+			now := time.Now()
+			month := now.Format("2006-01")
+			monthReturns := 0.0
+			for _, day := range analytics.DailyReturns {
+				if strings.HasPrefix(day.Date, month) {
+					monthReturns += day.TotalReturns
+				}
+			}
+			daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()).Day()
+			proRata := rule.Threshold * float64(now.Day()) / float64(daysInMonth)
+			percent := percentOf(monthReturns, analytics.TotalPortfolioValue)
+			rc.Summary = fmt.Sprintf("%.2f%% month to date (target %s%%, %.2f%% pro rata)", percent, formatThreshold(rule.Threshold), proRata)
+			if percent < proRata {
+				rc.warn()
+			}
+
+		case RuleZeroDay:
+			rc.Status = ComplianceManual
+			rc.Summary = fmt.Sprintf("Review the %d open positions", len(puts)+len(calls)+len(stocks))
+
+		case RuleMinReturn:
+			rc.Summary = "Open puts and calls above water"
+			for _, pos := range puts {
+				if pos.AnnualizedReturn < rule.Threshold {
+					rc.addOffender(optionLabel(pos), fmt.Sprintf("%.0f%% annualized at entry", pos.AnnualizedReturn), pos.OpenDate)
+				}
+			}
+			for _, pos := range calls {
+				if stock, ok := stocks[pos.Symbol]; ok && !isUnderwater(stock) && pos.AnnualizedReturn < rule.Threshold {
+					rc.addOffender(optionLabel(pos), fmt.Sprintf("%.0f%% annualized at entry", pos.AnnualizedReturn), pos.OpenDate)
+				}
+			}
+
+		case RuleCoveredCallUnderwater:
+			rc.Summary = "Open calls on underwater stock"
+			for _, pos := range calls {
+				stock, ok := stocks[pos.Symbol]
+				if !ok || !isUnderwater(stock) {
+					continue
+				}
+				costBasis := stock.CostBasis / stock.Shares
+				if pos.Strike < costBasis {
+					rc.addOffender(optionLabel(pos), fmt.Sprintf("Strike below $%.2f cost basis", costBasis), pos.OpenDate)
+				} else if pos.AnnualizedReturn < rule.Threshold {
+					rc.addOffender(optionLabel(pos), fmt.Sprintf("%.0f%% annualized at entry", pos.AnnualizedReturn), pos.OpenDate)
+				}
+			}
+
+		case RuleRollForCredit:
+			rc.Status = ComplianceManual
+			rc.Summary = "Checked when rolling with roll-finder"
+
+		case RuleVIXDeployment:
+			tier, ok := rules.VIXTier(vix)
+			if !ok {
+				rc.Status = ComplianceManual
+				rc.Summary = fmt.Sprintf("VIX %.2f, no deployment tiers configured", vix)
+				break
+			}
+			limit := netWorth * tier.MaxDeployment / 100
+			rc.Summary = fmt.Sprintf("VIX %.2f (%s): %.1f%% deployed (max %s%%)", vix, tier.Regime, percentOf(cash.ActiveCapital, netWorth), formatThreshold(tier.MaxDeployment))
+			if cash.ActiveCapital > limit {
+				rc.addOffender("Deployed capital", fmt.Sprintf("%s over the %s cap", FormatCurrency(cash.ActiveCapital-limit), FormatCurrency(limit)), violationSince(items, cash.ActiveCapital-limit))
+			} else if cash.ActiveCapital > limit*complianceWarnFraction {
+				rc.warn()
+			}
+		}
+
+		sort.Slice(rc.Offenders, func(a, b int) bool {
+			if rc.Offenders[a].Since != rc.Offenders[b].Since {
+				return rc.Offenders[a].Since < rc.Offenders[b].Since
+			}
+			return rc.Offenders[a].Name < rc.Offenders[b].Name
+		})
+		report = append(report, rc)
+	}
+
+	return report
+}
+
+// addOffender records a rule violation
+func (rc *RuleCompliance) addOffender(name, detail, since string) {
+	offender := ComplianceOffender{Name: name, Detail: detail, Since: since}
+	if t, err := time.Parse("2006-01-02", since); err == nil {
+		offender.DaysOpen = int(time.Since(t).Hours() / 24)
+	}
+	rc.Offenders = append(rc.Offenders, offender)
+	rc.Status = ComplianceViolation
+}
+
+// warn marks a rule as approaching its limit unless it's already violated
+func (rc *RuleCompliance) warn() {
+	if rc.Status == ComplianceCompliant {
+		rc.Status = ComplianceWarning
+	}
+}
+
+// StatusLabel returns a display label for the status
+func (rc RuleCompliance) StatusLabel() string {
+	switch rc.Status {
+	case ComplianceViolation:
+		return "Violation"
+	case ComplianceWarning:
+		return "Warning"
+	case ComplianceManual:
+		return "Manual"
+	case ComplianceDisabled:
+		return "Disabled"
+	default:
+		return "Compliant"
+	}
+}
+
+// violationSince dates a violation: removing positions newest first, it returns the open date
+// of the one that brings the excess back within the limit
+func violationSince(items []capitalItem, excess float64) string {
+	for i := len(items) - 1; i >= 0; i-- {
+		excess -= items[i].Amount
+		if excess <= 0 {
+			return items[i].Date
+		}
+	}
+	if len(items) > 0 {
+		return items[0].Date
+	}
+	return ""
+}
+
+// itemsWhere filters capital items by symbol
+func itemsWhere(items []capitalItem, keep func(symbol string) bool) []capitalItem {
+	var result []capitalItem
+	for _, item := range items {
+		if keep(item.Symbol) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func isUnderwater(stock Position) bool {
+	return stock.Shares > 0 && stock.CurrentPrice > 0 && stock.CurrentPrice < stock.CostBasis/stock.Shares
+}
+
+func optionLabel(pos OptionPosition) string {
+	return fmt.Sprintf("%s %s $%.2f exp %s", pos.Symbol, pos.OptionType, pos.Strike, pos.Expiry)
+}
+
+func percentOf(value, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return value / total * 100
+}
+
+func formatThreshold(threshold float64) string {
+	return fmt.Sprintf("%g", threshold)
+}
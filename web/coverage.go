@@ -0,0 +1,149 @@
+package web
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UncoveredCall is a symbol with more open call contracts than shares to cover them
+type UncoveredCall struct {
+	Symbol    string  `json:"symbol"`
+	Contracts int     `json:"contracts"` // Open call contracts
+	Shares    float64 `json:"shares"`    // Shares held
+	Uncovered int     `json:"uncovered"` // Contracts not backed by 100 shares each
+}
+
+// CoverageDay is the rule 2 coverage of open options at the end of a day
+type CoverageDay struct {
+	Date           string          `json:"date"`
+	UncoveredCalls []UncoveredCall `json:"uncoveredCalls"`
+	PutNotional    float64         `json:"putNotional"`  // Sum of open put strikes × 100 × contracts
+	Cash           float64         `json:"cash"`         // Brokerage cash before securing puts
+	PutShortfall   float64         `json:"putShortfall"` // Put notional not covered by cash
+}
+
+// CoverageReport lists the days on which options weren't fully secured
+type CoverageReport struct {
+	Current     CoverageDay   `json:"current"`
+	Issues      []CoverageDay `json:"issues"` // Days with uncovered calls or a put shortfall, oldest first
+	DaysChecked int           `json:"daysChecked"`
+}
+
+// HasIssue reports whether any call was uncovered or any put under-secured on the day
+func (d CoverageDay) HasIssue() bool {
+	return len(d.UncoveredCalls) > 0 || d.PutShortfall > 0
+}
+
+// UncoveredContracts returns the total uncovered call contracts on the day
+func (d CoverageDay) UncoveredContracts() int {
+	total := 0
+	for _, c := range d.UncoveredCalls {
+		total += c.Uncovered
+	}
+	return total
+}
+
+// CalculateCoverage replays the book day by day from the first trade and checks that open calls
+// are backed by 100 shares per contract and open puts by cash
+func CalculateCoverage(transactions []Transaction, stockTransactions []StockTransaction, optionTransactions []OptionTransaction) CoverageReport {
+	positions := CalculateOptionPositions(optionTransactions)
+
+	// Cash flows by date: deposits, option premiums and stock trades
+	cashFlows := make(map[string]float64)
+	start := ""
+	for _, t := range transactions {
+		date, err := time.Parse("January 2 2006", t.Date)
+		if err != nil || t.Type != "Deposit" {
+			continue
+		}
+		amount := strings.ReplaceAll(strings.TrimPrefix(t.Amount, "$"), ",", "")
+		if a, err := strconv.ParseFloat(amount, 64); err == nil {
+			day := date.Format("2006-01-02")
+			cashFlows[day] += a
+			if start == "" || day < start {
+				start = day
+			}
+		}
+	}
+	for _, tx := range optionTransactions {
+		cashFlows[tx.Date] += tx.Premium - tx.Commission
+	}
+
+	shareFlows := make(map[string]map[string]float64) // date -> symbol -> shares
+	for _, tx := range stockTransactions {
+		if shareFlows[tx.Date] == nil {
+			shareFlows[tx.Date] = make(map[string]float64)
+		}
+		switch tx.Type {
+		case "Buy":
+			shareFlows[tx.Date][tx.Symbol] += tx.Shares
+			cashFlows[tx.Date] -= tx.Amount + tx.Commission
+		case "Sell":
+			shareFlows[tx.Date][tx.Symbol] -= tx.Shares
+			cashFlows[tx.Date] += tx.Amount - tx.Commission
+		}
+		if start == "" || tx.Date < start {
+			start = tx.Date
+		}
+	}
+
+	var report CoverageReport
+	startDate, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return report
+	}
+
+	cash := 0.0
+	shares := make(map[string]float64)
+	today := time.Now().Format("2006-01-02")
+
+	for d := startDate; d.Format("2006-01-02") <= today; d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		cash += cashFlows[day]
+		for symbol, delta := range shareFlows[day] {
+			shares[symbol] += delta
+		}
+
+		coverage := CoverageDay{Date: day}
+		calls := make(map[string]int)
+		for _, pos := range positions {
+			if pos.OpenDate == "" || pos.OpenDate > day || (pos.CloseDate != "" && day >= pos.CloseDate) {
+				continue
+			}
+			if pos.OptionType == "Call" {
+				calls[pos.Symbol] += pos.Contracts
+			} else {
+				coverage.PutNotional += pos.Strike * 100 * float64(pos.Contracts)
+			}
+		}
+
+		for symbol, contracts := range calls {
+			covered := int(math.Floor(math.Max(0, shares[symbol]) / 100))
+			if contracts > covered {
+				coverage.UncoveredCalls = append(coverage.UncoveredCalls, UncoveredCall{
+					Symbol:    symbol,
+					Contracts: contracts,
+					Shares:    shares[symbol],
+					Uncovered: contracts - covered,
+				})
+			}
+		}
+		sort.Slice(coverage.UncoveredCalls, func(i, j int) bool {
+			return coverage.UncoveredCalls[i].Symbol < coverage.UncoveredCalls[j].Symbol
+		})
+
+		coverage.Cash = cash
+		coverage.PutShortfall = math.Max(0, coverage.PutNotional-cash)
+
+		report.DaysChecked++
+		report.Current = coverage
+		if coverage.HasIssue() {
+			report.Issues = append(report.Issues, coverage)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event types in data/events.csv
+const (
+	EventEarnings = "Earnings"
+	EventDividend = "Dividend"
+	EventNews     = "News"
+)
+
+// Event is a dated corporate or news event for a symbol
+type Event struct {
+	Symbol string
+	Date   string // 2006-01-02
+	Type   string // "Earnings", "Dividend", "News", ...
+	Notes  string
+}
+
+// Time returns the event date, or the zero time if it can't be parsed
+func (e Event) Time() time.Time {
+	t, _ := time.Parse("2006-01-02", e.Date)
+	return t
+}
+
+// IsEarnings reports whether the event is an earnings release
+func (e Event) IsEarnings() bool {
+	return strings.EqualFold(e.Type, EventEarnings)
+}
+
+// LoadEvents loads the event calendar from CSV (Symbol, Date, Type, Notes)
+func LoadEvents(filename string) []Event {
+	events, err := ReadEventsCSV(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading events CSV file: %v", err)
+		}
+		return []Event{}
+	}
+	return events
+}
+
+// ReadEventsCSV reads events from a CSV file with Symbol, Date and Type columns (Notes optional).
+// Dates may be 2006-01-02, 01/02/2006 or "January 2 2006".
+func ReadEventsCSV(filename string) ([]Event, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for i, record := range records {
+		if i == 0 || len(record) < 3 {
+			continue
+		}
+
+		date, err := parseEventDate(record[1])
+		if err != nil {
+			log.Printf("Skipping event %s on %q: %v", record[0], record[1], err)
+			continue
+		}
+
+		event := Event{
+			Symbol: strings.ToUpper(strings.TrimSpace(record[0])),
+			Date:   date.Format("2006-01-02"),
+			Type:   strings.TrimSpace(record[2]),
+		}
+		if len(record) > 3 {
+			event.Notes = record[3]
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// SaveEvents writes the event calendar to CSV, sorted by date then symbol
+func SaveEvents(filename string, events []Event) error {
+	sorted := append([]Event(nil), events...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Date != sorted[j].Date {
+			return sorted[i].Date < sorted[j].Date
+		}
+		return sorted[i].Symbol < sorted[j].Symbol
+	})
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Symbol", "Date", "Type", "Notes"}); err != nil {
+		return err
+	}
+	for _, e := range sorted {
+		if err := writer.Write([]string{e.Symbol, e.Date, e.Type, e.Notes}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MergeEvents adds imported events to the calendar, replacing the notes of events
+// already present (same symbol, date and type)
+func MergeEvents(existing, imported []Event) []Event {
+	key := func(e Event) string {
+		return e.Symbol + "|" + e.Date + "|" + strings.ToLower(e.Type)
+	}
+
+	merged := append([]Event(nil), existing...)
+	index := make(map[string]int)
+	for i, e := range merged {
+		index[key(e)] = i
+	}
+
+	for _, e := range imported {
+		if i, ok := index[key(e)]; ok {
+			if e.Notes != "" {
+				merged[i].Notes = e.Notes
+			}
+			continue
+		}
+		index[key(e)] = len(merged)
+		merged = append(merged, e)
+	}
+
+	return merged
+}
+
+// EventsBetween returns a symbol's events dated from start through end (inclusive), by date
+func EventsBetween(events []Event, symbol string, start, end time.Time) []Event {
+	from := start.Format("2006-01-02")
+	to := end.Format("2006-01-02")
+
+	var result []Event
+	for _, e := range events {
+		if e.Symbol == symbol && e.Date >= from && e.Date <= to {
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result
+}
+
+// UpcomingEvents returns a symbol's events from today through the next days
+func UpcomingEvents(events []Event, symbol string, days int) []Event {
+	now := time.Now()
+	return EventsBetween(events, symbol, now, now.AddDate(0, 0, days))
+}
+
+// NextEarnings returns the first earnings event dated from start through end
+func NextEarnings(events []Event, symbol string, start, end time.Time) (Event, bool) {
+	for _, e := range EventsBetween(events, symbol, start, end) {
+		if e.IsEarnings() {
+			return e, true
+		}
+	}
+	return Event{}, false
+}
+
+// FormatEvent formats an event for badges and tables (e.g., "Earnings Oct 28")
+func (e Event) FormatEvent() string {
+	t := e.Time()
+	if t.IsZero() {
+		return e.Type
+	}
+	return fmt.Sprintf("%s %s", e.Type, t.Format("Jan 2"))
+}
+
+func parseEventDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "01/02/2006", "January 2 2006", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format")
+}
@@ -121,6 +121,12 @@ func HandleStockPages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /stocks/AMD/volatility -> smile and term structure page
+	if base, ok := strings.CutSuffix(symbol, "/VOLATILITY"); ok {
+		handleVolatilityPage(w, base)
+		return
+	}
+
 	common := loadCommonData()
 
 	// Get symbol-specific data
@@ -141,12 +147,31 @@ func HandleStockPages(w http.ResponseWriter, r *http.Request) {
 		SymbolDetails: symbolDetails,
 		SymbolStocks:  symbolStocks,
 		SymbolOptions: symbolOptions,
+		SymbolEvents:  UpcomingEvents(LoadEvents("data/events.csv"), symbol, 90),
+	}
+	if ivStats, ok := CalculateIVStats(LoadIVHistory("data/iv_history.csv"), symbol); ok {
+		pageData.SymbolIV = &ivStats
 	}
 
 	enrichPageData(&pageData, common)
 	renderPage(w, "stocks/detail", pageData)
 }
 
+// handleVolatilityPage renders a symbol's volatility smile and term structure from the latest scan
+func handleVolatilityPage(w http.ResponseWriter, symbol string) {
+	pageData := PageData{
+		Title:       symbol + " - Volatility - mnmlsm",
+		CurrentPage: "stocks",
+		Symbol:      symbol,
+	}
+	if surface, ok := LoadVolSurfaces("data/vol_surfaces.json")[symbol]; ok {
+		pageData.Volatility = NewVolatilityPage(surface)
+	}
+
+	enrichPageData(&pageData, loadCommonData())
+	renderPage(w, "stocks/volatility", pageData)
+}
+
 // HandleAnalytics renders the analytics page with portfolio metrics
 func HandleAnalytics(w http.ResponseWriter, r *http.Request) {
 	common := loadCommonData()
@@ -245,6 +270,8 @@ func HandleRisk(w http.ResponseWriter, r *http.Request) {
 		// Position details data
 		PositionDetails:     positionDetails,
 		PositionDetailsJSON: positionDetailsJSON,
+		// Option coverage (rule 2)
+		Coverage: CalculateCoverage(LoadTransactionsFromCSV("data/transactions.csv"), LoadStockTransactions("data/stocks_transactions.csv"), LoadOptionTransactions("data/options_transactions.csv")),
 		// Analytics for additional metrics
 		TotalActiveCapital:          common.analytics.TotalActiveCapital,
 		TotalActiveCapitalFormatted: FormatCurrency(common.analytics.TotalActiveCapital),
@@ -264,6 +291,7 @@ func HandleRules(w http.ResponseWriter, r *http.Request) {
 	pageData := PageData{
 		Title:       "Rules - mnmlsm",
 		CurrentPage: "rules",
+		RulesCompliance: CalculateRulesCompliance(LoadTradingRules("data/rules.json"), common.analytics, common.vix),
 	}
 
 	enrichPageData(&pageData, common)
@@ -335,6 +363,12 @@ func renderPage(w http.ResponseWriter, page string, data PageData) {
 
 	funcMap := template.FuncMap{
 		"hasPrefix": strings.HasPrefix,
+		"formatIV":  FormatIV,
+		"volPoints": FormatVolPoints,
+		"maturity":  FormatMaturity,
+		"minIVObservations": func() int {
+			return MinIVObservations
+		},
 		"isPositive": func(s string) bool {
 			// Remove $ and commas, check if the number is positive
 			cleaned := strings.TrimPrefix(s, "$")
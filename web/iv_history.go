@@ -0,0 +1,239 @@
+package web
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IVWindows are the lookbacks IV rank and percentile are computed over, in daily observations
+// (trading days when IV is recorded every session)
+var IVWindows = []int{30, 90, 252}
+
+// MinIVObservations is the history a window needs before its rank and percentile are reported
+const MinIVObservations = 10
+
+// IVObservation is a symbol's at-the-money implied volatility on one day
+type IVObservation struct {
+	Symbol string
+	Date   string  // 2006-01-02
+	IV     float64 // Annualized, as a fraction (0.45 = 45%)
+}
+
+// LoadIVHistory loads the IV history from CSV (Symbol, Date, IV)
+func LoadIVHistory(filename string) []IVObservation {
+	history, err := ReadIVHistoryCSV(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading IV history CSV file: %v", err)
+		}
+		return []IVObservation{}
+	}
+	return history
+}
+
+// ReadIVHistoryCSV reads IV observations from a CSV file with Symbol, Date and IV columns
+func ReadIVHistoryCSV(filename string) ([]IVObservation, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var history []IVObservation
+	for i, record := range records {
+		if i == 0 || len(record) < 3 {
+			continue
+		}
+
+		iv, err := strconv.ParseFloat(record[2], 64)
+		if err != nil || iv <= 0 {
+			continue
+		}
+		// Observations recorded from gateway quotes before IVs were normalized are percentages
+		if iv > 5 {
+			iv /= 100
+		}
+
+		history = append(history, IVObservation{
+			Symbol: strings.ToUpper(strings.TrimSpace(record[0])),
+			Date:   strings.TrimSpace(record[1]),
+			IV:     iv,
+		})
+	}
+
+	return history, nil
+}
+
+// SaveIVHistory writes the IV history to CSV, sorted by symbol then date
+func SaveIVHistory(filename string, history []IVObservation) error {
+	sorted := append([]IVObservation(nil), history...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Symbol != sorted[j].Symbol {
+			return sorted[i].Symbol < sorted[j].Symbol
+		}
+		return sorted[i].Date < sorted[j].Date
+	})
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Symbol", "Date", "IV"}); err != nil {
+		return err
+	}
+	for _, o := range sorted {
+		if err := writer.Write([]string{o.Symbol, o.Date, fmt.Sprintf("%.4f", o.IV)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordIV adds an observation to the history, replacing the symbol's observation for the same day
+func RecordIV(history []IVObservation, observation IVObservation) []IVObservation {
+	for i, o := range history {
+		if o.Symbol == observation.Symbol && o.Date == observation.Date {
+			history[i] = observation
+			return history
+		}
+	}
+	return append(history, observation)
+}
+
+// SymbolIVHistory returns a symbol's observations, oldest first
+func SymbolIVHistory(history []IVObservation, symbol string) []IVObservation {
+	var result []IVObservation
+	for _, o := range history {
+		if o.Symbol == symbol {
+			result = append(result, o)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
+	return result
+}
+
+// IVWindowStats places the current IV within one lookback window
+type IVWindowStats struct {
+	Window       int     // Lookback in observations
+	Observations int     // Observations available, including the current one
+	Low          float64 // Lowest IV in the window
+	High         float64 // Highest IV in the window
+	Rank         float64 // Where the current IV sits between Low (0) and High (100)
+	Percentile   float64 // % of observations in the window below the current IV
+}
+
+// Ready reports whether the window has enough history for its rank to mean anything
+func (w IVWindowStats) Ready() bool {
+	return w.Observations >= MinIVObservations
+}
+
+// IVStats is a symbol's latest ATM IV with its rank and percentile over each of IVWindows
+type IVStats struct {
+	Symbol  string
+	Date    string  // Date of the current observation
+	Current float64 // Latest ATM IV as a fraction
+	Windows []IVWindowStats
+}
+
+// CalculateIVStats ranks a symbol's latest IV against its history.
+// Returns false if the symbol has no observations.
+func CalculateIVStats(history []IVObservation, symbol string) (IVStats, bool) {
+	observations := SymbolIVHistory(history, symbol)
+	if len(observations) == 0 {
+		return IVStats{}, false
+	}
+
+	latest := observations[len(observations)-1]
+	stats := IVStats{
+		Symbol:  symbol,
+		Date:    latest.Date,
+		Current: latest.IV,
+	}
+
+	for _, window := range IVWindows {
+		recent := observations
+		if len(recent) > window {
+			recent = recent[len(recent)-window:]
+		}
+
+		w := IVWindowStats{
+			Window:       window,
+			Observations: len(recent),
+			Low:          recent[0].IV,
+			High:         recent[0].IV,
+		}
+		below := 0
+		for _, o := range recent {
+			if o.IV < w.Low {
+				w.Low = o.IV
+			}
+			if o.IV > w.High {
+				w.High = o.IV
+			}
+			if o.IV < latest.IV {
+				below++
+			}
+		}
+
+		w.Rank = 50 // Flat history: neither high nor low
+		if w.High > w.Low {
+			w.Rank = (latest.IV - w.Low) / (w.High - w.Low) * 100
+		}
+		w.Percentile = float64(below) / float64(len(recent)) * 100
+
+		stats.Windows = append(stats.Windows, w)
+	}
+
+	return stats, true
+}
+
+// Best returns the longest window with its full history, or the shortest while it's still filling
+// up. Returns false until the shortest window has MinIVObservations.
+func (s IVStats) Best() (IVWindowStats, bool) {
+	for i := len(s.Windows) - 1; i >= 0; i-- {
+		if s.Windows[i].Observations >= s.Windows[i].Window {
+			return s.Windows[i], true
+		}
+	}
+	if len(s.Windows) > 0 && s.Windows[0].Ready() {
+		return s.Windows[0], true
+	}
+	return IVWindowStats{}, false
+}
+
+// FormatRank formats the rank and percentile of the best window for tables (e.g., "72 (p80, 252d)"),
+// or how much history there is while it's too short (e.g., "n/a (3/10d)")
+func (s IVStats) FormatRank() string {
+	best, ok := s.Best()
+	if !ok {
+		if len(s.Windows) == 0 {
+			return "n/a"
+		}
+		return fmt.Sprintf("n/a (%d/%dd)", s.Windows[0].Observations, MinIVObservations)
+	}
+	return fmt.Sprintf("%.0f (p%.0f, %dd)", best.Rank, best.Percentile, best.Window)
+}
+
+// FormatIV formats an IV fraction as a percentage (e.g., "45%")
+func FormatIV(iv float64) string {
+	return fmt.Sprintf("%.0f%%", iv*100)
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/csv"
+	"fmt"
 	"log"
 	"math"
 	"os"
@@ -39,12 +40,14 @@ type OptionPosition struct {
 	PremiumPaid       float64
 	NetPremium        float64
 	Commissions       float64
+	CommissionsEstimated bool  // True if any commission came from the commission schedule
 	MaxProfit         float64
 	DaysHeld          int
 	DaysToExpiry      int
 	AnnualizedReturn  float64
 	PercentReturn     float64
 	Capital           float64  // For calculating returns
+	Events            []Event  // Events from today through expiry (open positions only)
 }
 
 func LoadOptionTransactions(filename string) []OptionTransaction {
@@ -93,11 +96,53 @@ func LoadOptionTransactions(filename string) []OptionTransaction {
 	return transactions
 }
 
+// SaveOptionTransactions writes option transactions to CSV in the layout LoadOptionTransactions reads
+func SaveOptionTransactions(filename string, transactions []OptionTransaction) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{"Date", "Action", "Symbol", "OptionType", "Strike", "Expiry", "Contracts", "Premium", "StockPrice", "Commission", "PositionID", "Notes"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, tx := range transactions {
+		stockPrice := ""
+		if tx.StockPrice > 0 {
+			stockPrice = fmt.Sprintf("%.2f", tx.StockPrice)
+		}
+		record := []string{
+			tx.Date, tx.Action, tx.Symbol, tx.OptionType,
+			fmt.Sprintf("%.2f", tx.Strike), tx.Expiry, strconv.Itoa(tx.Contracts),
+			fmt.Sprintf("%.2f", tx.Premium), stockPrice, fmt.Sprintf("%.2f", tx.Commission),
+			tx.PositionID, tx.Notes,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func CalculateOptionPositions(transactions []OptionTransaction) []OptionPosition {
-	// Load stock transactions to get cost basis for covered calls
-	stockTransactions := LoadStockTransactions("data/stocks_transactions.csv")
-	stockCostBasis := calculateStockCostBasisAtDate(stockTransactions)
+	// Stock transactions for the cost basis of covered calls, the commission schedule for trades
+	// recorded without a commission and the event calendar for open positions that span earnings
+	return CalculateOptionPositionsFrom(transactions,
+		LoadStockTransactions("data/stocks_transactions.csv"),
+		LoadCommissionSchedule("data/commissions.json"),
+		LoadEvents("data/events.csv"))
+}
 
+// CalculateOptionPositionsFrom builds option positions from transactions against a given stock
+// ledger, commission schedule and event calendar instead of the portfolio's files. Covered call
+// capital is the cost basis of the shares held on the day the call was sold.
+func CalculateOptionPositionsFrom(transactions []OptionTransaction, stockTransactions []StockTransaction, commissions CommissionSchedule, events []Event) []OptionPosition {
 	positionMap := make(map[string]*OptionPosition)
 
 	for _, tx := range transactions {
@@ -120,12 +165,20 @@ func CalculateOptionPositions(transactions []OptionTransaction) []OptionPosition
 			positionMap[tx.PositionID] = pos
 		}
 
+		// Estimate commission and fees when the CSV doesn't record one
+		commission := tx.Commission
+		if commission == 0 && (tx.Action == "Sell to Open" || tx.Action == "Buy to Close") && tx.Contracts > 0 {
+			perShare := math.Abs(tx.Premium) / float64(tx.Contracts*100)
+			commission = commissions.OptionCommission(tx.Contracts, perShare, tx.Action == "Sell to Open")
+			pos.CommissionsEstimated = true
+		}
+
 		// Process the transaction
 		switch tx.Action {
 		case "Sell to Open":
 			pos.OpenDate = tx.Date
 			pos.PremiumCollected += tx.Premium
-			pos.Commissions += tx.Commission
+			pos.Commissions += commission
 
 			// Calculate capital requirement
 			if tx.OptionType == "Put" {
@@ -135,7 +188,7 @@ func CalculateOptionPositions(transactions []OptionTransaction) []OptionPosition
 				// Covered call - use actual stock cost basis for display/metrics
 				// This allows proper calculation of returns on covered calls
 				// Note: In analytics, we only count Put capital in TotalActiveCapital to avoid double-counting
-				if costBasis, exists := stockCostBasis[tx.Symbol]; exists {
+				if costBasis, exists := calculateStockCostBasisAtDate(stockTransactions, tx.Date)[tx.Symbol]; exists {
 					pos.Capital = costBasis * float64(tx.Contracts) * 100
 				} else {
 					// Fallback: use stock price at time of trade
@@ -145,7 +198,7 @@ func CalculateOptionPositions(transactions []OptionTransaction) []OptionPosition
 
 		case "Buy to Close":
 			pos.PremiumPaid += math.Abs(tx.Premium)
-			pos.Commissions += tx.Commission
+			pos.Commissions += commission
 			pos.CloseDate = tx.Date
 			// Check if this is a roll by looking for "roll" in the notes
 			if strings.Contains(strings.ToLower(tx.Notes), "roll") {
@@ -213,6 +266,13 @@ func CalculateOptionPositions(transactions []OptionTransaction) []OptionPosition
 			}
 		}
 
+		// Flag events before expiry on positions still open
+		if pos.Status == "Open" {
+			if expiryTime, err := time.Parse("2006-01-02", pos.Expiry); err == nil {
+				pos.Events = EventsBetween(events, pos.Symbol, time.Now(), expiryTime)
+			}
+		}
+
 		positions = append(positions, *pos)
 	}
 
@@ -224,6 +284,25 @@ func (p OptionPosition) FormatNetPremium() string {
 	return FormatCurrency(p.NetPremium)
 }
 
+// SpansEarnings reports whether an open position expires after an upcoming earnings date
+func (p OptionPosition) SpansEarnings() bool {
+	for _, e := range p.Events {
+		if e.IsEarnings() {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatEvents lists the position's upcoming events (e.g., "Earnings Oct 28")
+func (p OptionPosition) FormatEvents() string {
+	var parts []string
+	for _, e := range p.Events {
+		parts = append(parts, e.FormatEvent())
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (p OptionPosition) FormatCapital() string {
 	return FormatCurrency(p.Capital)
 }
@@ -237,12 +316,15 @@ func (p OptionPosition) FormatAnnualizedReturn() string {
 }
 
 // calculateStockCostBasisAtDate calculates the average cost basis per share for each symbol
-// based on current holdings (using FIFO lot tracking)
-func calculateStockCostBasisAtDate(transactions []StockTransaction) map[string]float64 {
+// based on holdings at the end of date (using FIFO lot tracking)
+func calculateStockCostBasisAtDate(transactions []StockTransaction, date string) map[string]float64 {
 	symbolLots := make(map[string][]Lot)
 
-	// Process transactions to build current holdings
+	// Process transactions to build holdings as of date
 	for _, tx := range transactions {
+		if tx.Date > date {
+			continue
+		}
 		if tx.Type == "Buy" {
 			lot := Lot{
 				Date:      tx.Date,
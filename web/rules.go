@@ -0,0 +1,143 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Rule IDs in data/rules.json
+const (
+	RuleNoMargin              = "no-margin"
+	RuleCashSecuredOnly       = "cash-secured-only"
+	RuleNoEarnings            = "no-earnings"
+	RuleDocumentRationale     = "document-rationale"
+	RuleCashMinimum           = "cash-minimum"
+	RuleMaxPosition           = "max-position"
+	RuleMaxSector             = "max-sector"
+	RuleMaxRisk               = "max-risk"
+	RuleMonthlyTarget         = "monthly-target"
+	RuleZeroDay               = "zero-day"
+	RuleMinReturn             = "min-return"
+	RuleCoveredCallUnderwater = "covered-call-underwater"
+	RuleRollForCredit         = "roll-for-credit"
+	RuleVIXDeployment         = "vix-deployment"
+)
+
+// Rule is one trading rule. Description may reference the threshold as {threshold}.
+type Rule struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Threshold   float64 `json:"threshold,omitempty"` // Percent of net worth, days, etc., depending on the rule
+	Enabled     bool    `json:"enabled"`
+}
+
+// VIXTier caps total deployment while the VIX is at or below MaxVIX
+type VIXTier struct {
+	MaxVIX        float64 `json:"maxVix"`        // Upper bound of the tier (0 = no upper bound)
+	Regime        string  `json:"regime"`        // e.g., "Normal", "Elevated"
+	MaxDeployment float64 `json:"maxDeployment"` // Max % of net worth deployed; the rest is kept in reserve
+}
+
+// TradingRules is the rules config in data/rules.json, in the order shown on the rules page
+type TradingRules struct {
+	Rules    []Rule    `json:"rules"`
+	VIXTiers []VIXTier `json:"vixTiers"` // Ascending by MaxVIX, used by the vix-deployment rule
+}
+
+// Text returns the rule description with its threshold filled in
+func (r Rule) Text() string {
+	return strings.ReplaceAll(r.Description, "{threshold}", strconv.FormatFloat(r.Threshold, 'f', -1, 64))
+}
+
+// DefaultTradingRules returns the rules we trade by
+func DefaultTradingRules() TradingRules {
+	return TradingRules{Rules: []Rule{
+		{ID: RuleNoMargin, Description: "No margin.", Enabled: true},
+		{ID: RuleCashSecuredOnly, Description: "Cash-secured puts and covered calls only.", Enabled: true},
+		{ID: RuleNoEarnings, Description: "No trading around earnings or news events (next {threshold} days).", Threshold: 14, Enabled: true},
+		{ID: RuleDocumentRationale, Description: "Document rationale for every trade.", Enabled: true},
+		{ID: RuleCashMinimum, Description: "Maintain {threshold}% cash minimum.", Threshold: 25, Enabled: true},
+		{ID: RuleMaxPosition, Description: "Maximum {threshold}% per position.", Threshold: 15, Enabled: true},
+		{ID: RuleMaxSector, Description: "Maximum {threshold}% per sector.", Threshold: 20, Enabled: true},
+		{ID: RuleMaxRisk, Description: "Maximum {threshold}% risk per trade.", Threshold: 2, Enabled: true},
+		{ID: RuleMonthlyTarget, Description: "Target {threshold}% monthly return.", Threshold: 4, Enabled: true},
+		{ID: RuleZeroDay, Description: "Zero-day rule: would I open this position today?", Enabled: true},
+		{ID: RuleMinReturn, Description: "Only enter new positions with >{threshold}% annualized return.", Threshold: 100, Enabled: true},
+		{ID: RuleCoveredCallUnderwater, Description: "If underwater, sell covered calls at cost basis for >{threshold}% annualized.", Threshold: 50, Enabled: true},
+		{ID: RuleRollForCredit, Description: "Roll for credit when possible (14-30 days out, lower strike).", Enabled: true},
+		{ID: RuleVIXDeployment, Description: "VIX-based deployment (VIX >{threshold} = reduce exposure).", Threshold: 25, Enabled: true},
+	}, VIXTiers: []VIXTier{
+		{MaxVIX: 25, Regime: "Normal", MaxDeployment: 75},
+		{MaxVIX: 35, Regime: "Elevated", MaxDeployment: 50},
+		{MaxVIX: 0, Regime: "Extreme", MaxDeployment: 25},
+	}}
+}
+
+// LoadTradingRules reads the rules config from JSON, falling back to the default rules
+func LoadTradingRules(filename string) TradingRules {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error opening rules config: %v", err)
+		}
+		return DefaultTradingRules()
+	}
+
+	var rules TradingRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Printf("Error parsing rules config: %v", err)
+		return DefaultTradingRules()
+	}
+
+	return rules
+}
+
+// Rule returns the rule with the given ID
+func (t TradingRules) Rule(id string) (Rule, bool) {
+	for _, r := range t.Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Enabled reports whether a rule is present and enabled
+func (t TradingRules) Enabled(id string) bool {
+	r, ok := t.Rule(id)
+	return ok && r.Enabled
+}
+
+// Threshold returns a rule's threshold (0 if the rule is missing)
+func (t TradingRules) Threshold(id string) float64 {
+	r, _ := t.Rule(id)
+	return r.Threshold
+}
+
+// VIXTier returns the deployment tier for a VIX level
+func (t TradingRules) VIXTier(vix float64) (VIXTier, bool) {
+	for _, tier := range t.VIXTiers {
+		if tier.MaxVIX == 0 || vix <= tier.MaxVIX {
+			return tier, true
+		}
+	}
+	return VIXTier{}, false
+}
+
+// ReservePercent returns the % of net worth to keep in cash: the cash minimum, raised by the
+// VIX tier when the vix-deployment rule allows less than the rest to be deployed
+func (t TradingRules) ReservePercent(vix float64) float64 {
+	reserve := 0.0
+	if t.Enabled(RuleCashMinimum) {
+		reserve = t.Threshold(RuleCashMinimum)
+	}
+	if t.Enabled(RuleVIXDeployment) {
+		if tier, ok := t.VIXTier(vix); ok && 100-tier.MaxDeployment > reserve {
+			reserve = 100 - tier.MaxDeployment
+		}
+	}
+	return reserve
+}
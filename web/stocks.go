@@ -105,6 +105,34 @@ func LoadStockTransactions(filename string) []StockTransaction {
 	return transactions
 }
 
+// SaveStockTransactions writes stock transactions to CSV in the layout LoadStockTransactions reads
+func SaveStockTransactions(filename string, transactions []StockTransaction) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Date", "Type", "Symbol", "Shares", "Price", "Amount", "Commission"}); err != nil {
+		return err
+	}
+	for _, tx := range transactions {
+		record := []string{
+			tx.Date, tx.Type, tx.Symbol,
+			strconv.FormatFloat(tx.Shares, 'f', -1, 64),
+			fmt.Sprintf("%.2f", tx.Price), fmt.Sprintf("%.2f", tx.Amount), fmt.Sprintf("%.2f", tx.Commission),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func LoadStockPrices(filename string) map[string]float64 {
 	file, err := os.Open(filename)
 	if err != nil {
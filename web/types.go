@@ -68,6 +68,9 @@ type PageData struct {
 	SymbolDetails   SymbolDetails     // For individual stock detail page
 	SymbolStocks    []Stock           // Filtered stocks for this symbol
 	SymbolOptions   []OptionPosition  // Filtered options for this symbol
+	SymbolEvents    []Event           // Upcoming events for this symbol
+	SymbolIV        *IVStats          // ATM IV rank and percentile for this symbol (nil = no IV history)
+	Volatility      *VolatilityPage   // Smile and term structure from the latest scan (nil = not scanned)
 	// Stock performance data
 	StockPerformance StockPerformance
 	// Options performance data
@@ -86,6 +89,8 @@ type PageData struct {
 	// Position details data
 	PositionDetails     []PositionDetail
 	PositionDetailsJSON string
+	// Option coverage data
+	Coverage CoverageReport
 	// Time-Weighted Return data
 	TimeWeightedReturn                   float64
 	TimeWeightedReturnAnnualized         float64
@@ -94,6 +99,8 @@ type PageData struct {
 	// Projected $1M data
 	ProjectedMillionDateFormatted string
 	DaysToMillion                 int
+	// Trading rules compliance
+	RulesCompliance []RuleCompliance
 }
 
 type CashPosition struct {
@@ -0,0 +1,382 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// SkewDelta is the |delta| the smile's skew is read at
+const SkewDelta = 0.25
+
+// VolPoint is one quoted contract on an expiry's volatility smile
+type VolPoint struct {
+	Strike    float64 `json:"strike"`
+	Right     string  `json:"right"`
+	Delta     float64 `json:"delta"`
+	IV        float64 `json:"iv"`        // Annualized, as a fraction
+	Mid       float64 `json:"mid"`       // Per share
+	Moneyness float64 `json:"moneyness"` // ln(strike / price)
+	Fitted    float64 `json:"fitted"`    // IV of the fitted smile at this strike (0 = no fit)
+	Richness  float64 `json:"richness"`  // IV − Fitted: above 0 the contract is priced rich to the curve
+}
+
+// VolSmile is the implied volatility across strikes of one expiry
+type VolSmile struct {
+	MaturityDate string     `json:"maturityDate"` // 20060102
+	DTE          int        `json:"dte"`
+	ATMIV        float64    `json:"atmIv"`    // IV at the strike nearest the price
+	Put25IV      float64    `json:"put25Iv"`  // IV of the 25-delta put, interpolated (0 = strikes don't reach it)
+	Call25IV     float64    `json:"call25Iv"` // IV of the 25-delta call, interpolated (0 = strikes don't reach it)
+	PutSkew      float64    `json:"putSkew"`  // Put25IV − ATMIV (0 = unknown)
+	CallSkew     float64    `json:"callSkew"` // Call25IV − ATMIV (0 = unknown)
+	Fit          []float64  `json:"fit"`      // a, b, c of IV = a + b·k + c·k² with k the moneyness (nil = under 3 strikes)
+	Points       []VolPoint `json:"points"`   // By strike, puts first
+}
+
+// TermPoint is one expiry on the term structure of ATM IV
+type TermPoint struct {
+	MaturityDate string  `json:"maturityDate"`
+	DTE          int     `json:"dte"`
+	ATMIV        float64 `json:"atmIv"`
+	ForwardIV    float64 `json:"forwardIv"` // IV implied between the previous expiry and this one (0 for the first)
+	Fitted       float64 `json:"fitted"`    // ATM IV of the term structure line at this expiry (0 = under 3 expiries)
+	Richness     float64 `json:"richness"`  // ATMIV − Fitted
+}
+
+// VolSurface is a symbol's smiles and term structure from one scan
+type VolSurface struct {
+	Symbol string      `json:"symbol"`
+	Time   time.Time   `json:"time"`
+	Price  float64     `json:"price"`
+	Smiles []VolSmile  `json:"smiles"` // Nearest expiry first
+	Term   []TermPoint `json:"term"`
+}
+
+// VolatilityPage is what the volatility page shows of a symbol's latest surface
+type VolatilityPage struct {
+	Surface         VolSurface
+	Richest         *VolPoint  // Contract furthest above its smile (nil = no fitted smile)
+	RichestMaturity string     // Expiry of Richest
+	RichestExpiry   *TermPoint // Expiry furthest above the term structure (nil = under 3 expiries)
+	SurfaceJSON     string     // Surface for the charts
+}
+
+// NewVolatilityPage picks the richest contract and expiry of a surface for display
+func NewVolatilityPage(surface VolSurface) *VolatilityPage {
+	page := &VolatilityPage{Surface: surface, SurfaceJSON: "null"}
+	if smile, point, ok := surface.Richest(); ok {
+		page.Richest = &point
+		page.RichestMaturity = smile.MaturityDate
+	}
+	if expiry, ok := surface.RichestExpiry(); ok {
+		page.RichestExpiry = &expiry
+	}
+	if data, err := json.Marshal(surface); err == nil {
+		page.SurfaceJSON = string(data)
+	}
+	return page
+}
+
+// FormatVolPoints formats an IV difference in vol points with its sign (e.g., "+4.2")
+func FormatVolPoints(diff float64) string {
+	return fmt.Sprintf("%+.1f", diff*100)
+}
+
+// FormatMaturity formats a 20060102 maturity date for display (e.g., "Oct 24")
+func FormatMaturity(maturity string) string {
+	date, err := time.Parse("20060102", maturity)
+	if err != nil {
+		return maturity
+	}
+	return date.Format("Jan 2")
+}
+
+// LoadVolSurfaces loads the latest surface of each symbol from JSON
+func LoadVolSurfaces(filename string) map[string]VolSurface {
+	surfaces := make(map[string]VolSurface)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error reading volatility surfaces file: %v", err)
+		}
+		return surfaces
+	}
+
+	if err := json.Unmarshal(data, &surfaces); err != nil {
+		log.Printf("Error parsing volatility surfaces file: %v", err)
+		return make(map[string]VolSurface)
+	}
+	return surfaces
+}
+
+// RecordVolSurfaces replaces the saved surface of each given symbol and writes the file
+func RecordVolSurfaces(filename string, surfaces []VolSurface) error {
+	saved := LoadVolSurfaces(filename)
+	for _, surface := range surfaces {
+		saved[surface.Symbol] = surface
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// BuildVolSurface builds the term structure over a symbol's smiles, ordering them nearest first
+func BuildVolSurface(symbol string, price float64, at time.Time, smiles []VolSmile) VolSurface {
+	sort.Slice(smiles, func(i, j int) bool {
+		return smiles[i].DTE < smiles[j].DTE
+	})
+
+	surface := VolSurface{Symbol: symbol, Time: at, Price: price, Smiles: smiles}
+	for _, smile := range smiles {
+		if smile.ATMIV <= 0 {
+			continue
+		}
+		point := TermPoint{MaturityDate: smile.MaturityDate, DTE: smile.DTE, ATMIV: smile.ATMIV}
+		if n := len(surface.Term); n > 0 {
+			point.ForwardIV = forwardVol(surface.Term[n-1], point)
+		}
+		surface.Term = append(surface.Term, point)
+	}
+
+	// Line through ATM IV against √DTE, so each expiry can be read against its neighbours
+	if len(surface.Term) >= 3 {
+		xs := make([]float64, len(surface.Term))
+		ys := make([]float64, len(surface.Term))
+		for i, point := range surface.Term {
+			xs[i], ys[i] = math.Sqrt(float64(point.DTE)), point.ATMIV
+		}
+		if fit := fitPolynomial(xs, ys, 1); fit != nil {
+			for i := range surface.Term {
+				surface.Term[i].Fitted = evalPolynomial(fit, xs[i])
+				surface.Term[i].Richness = surface.Term[i].ATMIV - surface.Term[i].Fitted
+			}
+		}
+	}
+
+	return surface
+}
+
+// BuildVolSmile reads the ATM IV, 25-delta skew and fitted curve of one expiry from its quotes.
+// Points without IV are dropped.
+func BuildVolSmile(maturityDate string, dte int, price float64, points []VolPoint) VolSmile {
+	smile := VolSmile{MaturityDate: maturityDate, DTE: dte}
+	for _, point := range points {
+		if point.IV > 0 && point.Strike > 0 && price > 0 {
+			point.Moneyness = math.Log(point.Strike / price)
+			smile.Points = append(smile.Points, point)
+		}
+	}
+	sort.Slice(smile.Points, func(i, j int) bool {
+		if smile.Points[i].Right != smile.Points[j].Right {
+			return smile.Points[i].Right == "P"
+		}
+		return smile.Points[i].Strike < smile.Points[j].Strike
+	})
+	if len(smile.Points) == 0 {
+		return smile
+	}
+
+	// ATM: average of the quotes at the strike nearest the price
+	nearest := smile.Points[0].Strike
+	for _, point := range smile.Points {
+		if math.Abs(point.Strike-price) < math.Abs(nearest-price) {
+			nearest = point.Strike
+		}
+	}
+	sum, n := 0.0, 0
+	for _, point := range smile.Points {
+		if point.Strike == nearest {
+			sum += point.IV
+			n++
+		}
+	}
+	smile.ATMIV = sum / float64(n)
+
+	smile.Put25IV = deltaIV(smile.Points, "P", SkewDelta)
+	smile.Call25IV = deltaIV(smile.Points, "C", SkewDelta)
+	if smile.Put25IV > 0 {
+		smile.PutSkew = smile.Put25IV - smile.ATMIV
+	}
+	if smile.Call25IV > 0 {
+		smile.CallSkew = smile.Call25IV - smile.ATMIV
+	}
+
+	strikes := make(map[float64]bool)
+	xs := make([]float64, len(smile.Points))
+	ys := make([]float64, len(smile.Points))
+	for i, point := range smile.Points {
+		strikes[point.Strike] = true
+		xs[i], ys[i] = point.Moneyness, point.IV
+	}
+	if len(strikes) >= 3 {
+		smile.Fit = fitPolynomial(xs, ys, 2)
+	}
+	if smile.Fit != nil {
+		for i := range smile.Points {
+			smile.Points[i].Fitted = evalPolynomial(smile.Fit, smile.Points[i].Moneyness)
+			smile.Points[i].Richness = smile.Points[i].IV - smile.Points[i].Fitted
+		}
+	}
+
+	return smile
+}
+
+// Richest returns the contract priced furthest above its expiry's fitted smile.
+// Returns false when no smile has a fit.
+func (s VolSurface) Richest() (VolSmile, VolPoint, bool) {
+	var bestSmile VolSmile
+	var best VolPoint
+	found := false
+	for _, smile := range s.Smiles {
+		for _, point := range smile.Points {
+			if point.Fitted > 0 && (!found || point.Richness > best.Richness) {
+				bestSmile, best, found = smile, point, true
+			}
+		}
+	}
+	return bestSmile, best, found
+}
+
+// RichestExpiry returns the expiry whose ATM IV sits furthest above the term structure line.
+// Returns false under 3 expiries.
+func (s VolSurface) RichestExpiry() (TermPoint, bool) {
+	var best TermPoint
+	found := false
+	for _, point := range s.Term {
+		if point.Fitted > 0 && (!found || point.Richness > best.Richness) {
+			best, found = point, true
+		}
+	}
+	return best, found
+}
+
+// TermShape describes the term structure: "contango" when later expiries carry more IV than
+// the nearest, "backwardation" when less, "flat" within one vol point, "" under 2 expiries
+func (s VolSurface) TermShape() string {
+	if len(s.Term) < 2 {
+		return ""
+	}
+	slope := s.Term[len(s.Term)-1].ATMIV - s.Term[0].ATMIV
+	switch {
+	case slope > 0.01:
+		return "contango"
+	case slope < -0.01:
+		return "backwardation"
+	default:
+		return "flat"
+	}
+}
+
+// deltaIV interpolates the IV at a |delta| between the quoted contracts of one right that
+// straddle it. Returns 0 when the quotes don't reach it on both sides.
+func deltaIV(points []VolPoint, right string, target float64) float64 {
+	var quoted []VolPoint
+	for _, point := range points {
+		if point.Right == right && point.Delta != 0 {
+			quoted = append(quoted, point)
+		}
+	}
+	sort.Slice(quoted, func(i, j int) bool {
+		return math.Abs(quoted[i].Delta) < math.Abs(quoted[j].Delta)
+	})
+
+	for i := 1; i < len(quoted); i++ {
+		lo, hi := math.Abs(quoted[i-1].Delta), math.Abs(quoted[i].Delta)
+		if lo <= target && target <= hi {
+			if hi == lo {
+				return quoted[i].IV
+			}
+			weight := (target - lo) / (hi - lo)
+			return quoted[i-1].IV + weight*(quoted[i].IV-quoted[i-1].IV)
+		}
+	}
+	return 0
+}
+
+// forwardVol is the IV the market implies between two expiries, from total variance
+// σ₂²T₂ − σ₁²T₁ over T₂ − T₁. Returns 0 when the variance would be negative.
+func forwardVol(near, far TermPoint) float64 {
+	if far.DTE <= near.DTE {
+		return 0
+	}
+	t1, t2 := float64(near.DTE)/365, float64(far.DTE)/365
+	variance := (far.ATMIV*far.ATMIV*t2 - near.ATMIV*near.ATMIV*t1) / (t2 - t1)
+	if variance <= 0 {
+		return 0
+	}
+	return math.Sqrt(variance)
+}
+
+// fitPolynomial fits y = c₀ + c₁x + … + c_d x^d by least squares. Returns nil when the
+// points can't determine the curve.
+func fitPolynomial(xs, ys []float64, degree int) []float64 {
+	n := degree + 1
+	if len(xs) < n {
+		return nil
+	}
+
+	// Normal equations: (XᵀX) c = Xᵀy, solved by Gaussian elimination with partial pivoting
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for k, x := range xs {
+		powers := make([]float64, 2*n)
+		powers[0] = 1
+		for p := 1; p < len(powers); p++ {
+			powers[p] = powers[p-1] * x
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				a[i][j] += powers[i+j]
+			}
+			a[i][n] += powers[i] * ys[k]
+		}
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for j := col; j <= n; j++ {
+				a[row][j] -= factor * a[col][j]
+			}
+		}
+	}
+
+	coefficients := make([]float64, n)
+	for i := range coefficients {
+		coefficients[i] = a[i][n] / a[i][i]
+	}
+	return coefficients
+}
+
+// evalPolynomial evaluates coefficients c₀ + c₁x + … at x
+func evalPolynomial(coefficients []float64, x float64) float64 {
+	y := 0.0
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = y*x + coefficients[i]
+	}
+	return y
+}